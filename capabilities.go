@@ -0,0 +1,143 @@
+package gostorage
+
+import "strings"
+
+// Capability is a set of optional features a driver supports beyond the Driver interface.
+type Capability uint64
+
+const (
+	// CapabilityContext is supported by drivers implementing DriverContext.
+	CapabilityContext Capability = 1 << iota
+	// CapabilityStreamRead is supported by drivers implementing StreamReader.
+	CapabilityStreamRead
+	// CapabilityRangeRead is supported by drivers implementing RangeReader.
+	CapabilityRangeRead
+	// CapabilityStat is supported by drivers implementing Stater.
+	CapabilityStat
+	// CapabilityWriteOptions is supported by drivers implementing OptionsWriter.
+	CapabilityWriteOptions
+	// CapabilityConditionalWrite is supported by drivers that guarantee the conditions
+	// WriteOptions.IfNotExists and WriteOptions.IfMatch atomically.
+	CapabilityConditionalWrite
+	// CapabilityHierarchicalList is supported by drivers implementing Lister.
+	CapabilityHierarchicalList
+	// CapabilityCopy is supported by drivers implementing Copier.
+	CapabilityCopy
+	// CapabilityMove is supported by drivers implementing Mover.
+	CapabilityMove
+	// CapabilityBatchDelete is supported by drivers implementing BatchDeleter.
+	CapabilityBatchDelete
+	// CapabilityPrefixDelete is supported by drivers implementing PrefixDeleter.
+	CapabilityPrefixDelete
+	// CapabilityPresignedURL is supported by drivers that are able to sign URLs
+	// granting temporary access to a file/object.
+	CapabilityPresignedURL
+	// CapabilityVersioning is supported by drivers implementing Versioner that keep
+	// previous versions of a file/object.
+	CapabilityVersioning
+	// CapabilityMultipartUpload is supported by drivers implementing MultipartUploader.
+	CapabilityMultipartUpload
+)
+
+// capabilityNames contains the names of the capabilities in the order of their bits.
+var capabilityNames = []string{
+	"context",
+	"stream-read",
+	"range-read",
+	"stat",
+	"write-options",
+	"conditional-write",
+	"hierarchical-list",
+	"copy",
+	"move",
+	"batch-delete",
+	"prefix-delete",
+	"presigned-url",
+	"versioning",
+	"multipart-upload",
+}
+
+// Has reports whether c contains all capabilities of other.
+func (c Capability) Has(other Capability) bool {
+	return c&other == other
+}
+
+// String returns the names of the capabilities separated by "|".
+func (c Capability) String() string {
+	names := []string{}
+	for i, name := range capabilityNames {
+		if c.Has(1 << i) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// CapabilityReporter is implemented by drivers that report their capabilities themselves.
+type CapabilityReporter interface {
+	// Capabilities returns the optional features supported by the driver.
+	Capabilities() Capability
+}
+
+// CapabilityChecker is implemented by drivers that determine some of their capabilities
+// by a request, like whether versioning is enabled for a bucket, so that only the
+// capabilities asked for are determined.
+type CapabilityChecker interface {
+	// HasCapability reports whether the driver supports all capabilities of c.
+	HasCapability(c Capability) bool
+}
+
+// HasCapability reports whether the driver supports all capabilities of c.
+// If the driver implements CapabilityChecker, it is asked for c only.
+// Otherwise c is compared with the capabilities returned by CapabilitiesOf.
+func HasCapability(d Driver, c Capability) bool {
+	if cc, ok := d.(CapabilityChecker); ok {
+		return cc.HasCapability(c)
+	}
+	return CapabilitiesOf(d).Has(c)
+}
+
+// CapabilitiesOf returns the capabilities of the driver. If the driver does not implement
+// CapabilityReporter, the capabilities are derived from the optional interfaces it implements.
+// Capabilities without an interface, like CapabilityConditionalWrite, are never derived.
+func CapabilitiesOf(d Driver) Capability {
+	if cr, ok := d.(CapabilityReporter); ok {
+		return cr.Capabilities()
+	}
+
+	var c Capability
+	if _, ok := d.(DriverContext); ok {
+		c |= CapabilityContext
+	}
+	if _, ok := d.(StreamReader); ok {
+		c |= CapabilityStreamRead
+	}
+	if _, ok := d.(RangeReader); ok {
+		c |= CapabilityRangeRead
+	}
+	if _, ok := d.(Stater); ok {
+		c |= CapabilityStat
+	}
+	if _, ok := d.(OptionsWriter); ok {
+		c |= CapabilityWriteOptions
+	}
+	if _, ok := d.(Lister); ok {
+		c |= CapabilityHierarchicalList
+	}
+	if _, ok := d.(Copier); ok {
+		c |= CapabilityCopy
+	}
+	if _, ok := d.(Mover); ok {
+		c |= CapabilityMove
+	}
+	if _, ok := d.(BatchDeleter); ok {
+		c |= CapabilityBatchDelete
+	}
+	if _, ok := d.(PrefixDeleter); ok {
+		c |= CapabilityPrefixDelete
+	}
+	if _, ok := d.(MultipartUploader); ok {
+		c |= CapabilityMultipartUpload
+	}
+	return c
+}
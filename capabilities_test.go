@@ -0,0 +1,194 @@
+package gostorage
+
+import "testing"
+
+// reportingDriver is a memoryDriver that reports its capabilities itself.
+type reportingDriver struct {
+	memoryDriver
+	capabilities Capability
+}
+
+func (r reportingDriver) Capabilities() Capability {
+	return r.capabilities
+}
+
+// checkingDriver is a reportingDriver that checks the capabilities asked for itself.
+type checkingDriver struct {
+	reportingDriver
+	checked *Capability
+}
+
+func (c checkingDriver) HasCapability(capability Capability) bool {
+	*c.checked |= capability
+	return c.capabilities.Has(capability)
+}
+
+func TestCapability_Has(t *testing.T) {
+	tests := []struct {
+		name  string
+		c     Capability
+		other Capability
+		want  bool
+	}{
+		{
+			name:  "contained",
+			c:     CapabilityCopy | CapabilityMove,
+			other: CapabilityCopy,
+			want:  true,
+		},
+		{
+			name:  "all contained",
+			c:     CapabilityCopy | CapabilityMove,
+			other: CapabilityCopy | CapabilityMove,
+			want:  true,
+		},
+		{
+			name:  "partially contained",
+			c:     CapabilityCopy,
+			other: CapabilityCopy | CapabilityMove,
+			want:  false,
+		},
+		{
+			name:  "not contained",
+			c:     CapabilityCopy,
+			other: CapabilityVersioning,
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Has(tt.other); got != tt.want {
+				t.Errorf("Capability.Has() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapability_String(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Capability
+		want string
+	}{
+		{
+			name: "none",
+			c:    0,
+			want: "",
+		},
+		{
+			name: "single",
+			c:    CapabilityRangeRead,
+			want: "range-read",
+		},
+		{
+			name: "multiple",
+			c:    CapabilityVersioning | CapabilityContext | CapabilityCopy,
+			want: "context|copy|versioning",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.String(); got != tt.want {
+				t.Errorf("Capability.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesOf(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Driver
+		want Capability
+	}{
+		{
+			name: "plain driver",
+			d:    memoryDriver{},
+			want: 0,
+		},
+		{
+			name: "context driver",
+			d:    contextDriver{driver: AsDriverContext(memoryDriver{})},
+			want: 0,
+		},
+		{
+			name: "multipart driver",
+			d:    &multipartDriver{memoryDriver: memoryDriver{}},
+			want: CapabilityMultipartUpload,
+		},
+		{
+			name: "reporting driver",
+			d:    reportingDriver{capabilities: CapabilityConditionalWrite},
+			want: CapabilityConditionalWrite,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CapabilitiesOf(tt.d); got != tt.want {
+				t.Errorf("CapabilitiesOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasCapability(t *testing.T) {
+	tests := []struct {
+		name       string
+		d          func(checked *Capability) Driver
+		capability Capability
+		want       bool
+		wantCheck  Capability
+	}{
+		{
+			name: "plain driver",
+			d: func(checked *Capability) Driver {
+				return memoryDriver{}
+			},
+			capability: CapabilityConditionalWrite,
+			want:       false,
+		},
+		{
+			name: "reporting driver",
+			d: func(checked *Capability) Driver {
+				return reportingDriver{capabilities: CapabilityConditionalWrite}
+			},
+			capability: CapabilityConditionalWrite,
+			want:       true,
+		},
+		{
+			name: "checking driver",
+			d: func(checked *Capability) Driver {
+				return checkingDriver{
+					reportingDriver: reportingDriver{capabilities: CapabilityConditionalWrite | CapabilityVersioning},
+					checked:         checked,
+				}
+			},
+			capability: CapabilityConditionalWrite,
+			want:       true,
+			wantCheck:  CapabilityConditionalWrite,
+		},
+		{
+			name: "checking driver without the capability",
+			d: func(checked *Capability) Driver {
+				return checkingDriver{
+					reportingDriver: reportingDriver{capabilities: CapabilityConditionalWrite},
+					checked:         checked,
+				}
+			},
+			capability: CapabilityConditionalWrite | CapabilityVersioning,
+			want:       false,
+			wantCheck:  CapabilityConditionalWrite | CapabilityVersioning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checked Capability
+			if got := HasCapability(tt.d(&checked), tt.capability); got != tt.want {
+				t.Errorf("HasCapability() = %v, want %v", got, tt.want)
+			}
+			if checked != tt.wantCheck {
+				t.Errorf("HasCapability() checked %v, want %v", checked, tt.wantCheck)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package gostorage
+
+import (
+	"context"
+	"io"
+
+	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
+)
+
+// AsDriverContext returns the DriverContext for the given driver.
+// If the driver implements DriverContext itself, it is returned as is.
+// Otherwise the driver is wrapped and the context is checked before every call,
+// so that a cancelled or expired context prevents the operation from being started.
+func AsDriverContext(d Driver) DriverContext {
+	if dc, ok := d.(DriverContext); ok {
+		return dc
+	}
+	return driverContext{driver: d}
+}
+
+// AsDriver returns a Driver that calls the given DriverContext with context.Background().
+// If the DriverContext implements Driver itself or has been created by AsDriverContext,
+// the underlying driver is returned.
+func AsDriver(dc DriverContext) Driver {
+	switch d := dc.(type) {
+	case Driver:
+		return d
+	case driverContext:
+		return d.driver
+	}
+	return contextDriver{driver: dc}
+}
+
+// driverContext wraps a Driver that is not context aware.
+type driverContext struct {
+	driver Driver
+}
+
+func (d driverContext) ReadContext(ctx context.Context, key string) (io.Reader, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return d.driver.Read(key)
+}
+
+func (d driverContext) WriteContext(ctx context.Context, key string, value io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.driver.Write(key, utils.ContextReader(ctx, value))
+}
+
+func (d driverContext) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.driver.Delete(key)
+}
+
+func (d driverContext) ExistsContext(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return d.driver.Exists(key)
+}
+
+func (d driverContext) ListContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return d.driver.List()
+}
+
+// contextDriver wraps a DriverContext that does not implement Driver.
+type contextDriver struct {
+	driver DriverContext
+}
+
+func (d contextDriver) Read(key string) (io.Reader, error) {
+	return d.driver.ReadContext(context.Background(), key)
+}
+
+func (d contextDriver) Write(key string, value io.Reader) error {
+	return d.driver.WriteContext(context.Background(), key, value)
+}
+
+func (d contextDriver) Delete(key string) error {
+	return d.driver.DeleteContext(context.Background(), key)
+}
+
+func (d contextDriver) Exists(key string) (bool, error) {
+	return d.driver.ExistsContext(context.Background(), key)
+}
+
+func (d contextDriver) List() ([]string, error) {
+	return d.driver.ListContext(context.Background())
+}
@@ -0,0 +1,127 @@
+package gostorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// memoryDriver is a minimal in-memory Driver that is not context aware.
+type memoryDriver map[string][]byte
+
+func (m memoryDriver) Read(key string) (io.Reader, error) {
+	bts, ok := m[key]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return bytes.NewReader(bts), nil
+}
+
+func (m memoryDriver) Write(key string, value io.Reader) error {
+	bts, err := ioutil.ReadAll(value)
+	if err != nil {
+		return err
+	}
+	m[key] = bts
+	return nil
+}
+
+func (m memoryDriver) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memoryDriver) Exists(key string) (bool, error) {
+	_, ok := m[key]
+	return ok, nil
+}
+
+func (m memoryDriver) List() ([]string, error) {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func TestAsDriverContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{
+			name:    "active context",
+			ctx:     context.Background(),
+			wantErr: nil,
+		},
+		{
+			name:    "cancelled context",
+			ctx:     cancelled,
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := AsDriverContext(memoryDriver{"test.txt": []byte("test")})
+
+			err := dc.WriteContext(tt.ctx, "test2.txt", strings.NewReader("test2"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WriteContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			_, err = dc.ReadContext(tt.ctx, "test.txt")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			_, err = dc.ExistsContext(tt.ctx, "test.txt")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ExistsContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			_, err = dc.ListContext(tt.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ListContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			err = dc.DeleteContext(tt.ctx, "test.txt")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAsDriver(t *testing.T) {
+	d := AsDriver(AsDriverContext(memoryDriver{}))
+	if _, ok := d.(memoryDriver); !ok {
+		t.Errorf("AsDriver() = %T, want the wrapped memoryDriver", d)
+	}
+
+	d = AsDriver(struct{ DriverContext }{AsDriverContext(memoryDriver{})})
+	err := d.Write("test.txt", strings.NewReader("test"))
+	if err != nil {
+		t.Errorf("Write() error = %v", err)
+		return
+	}
+	got, err := d.Read("test.txt")
+	if err != nil {
+		t.Errorf("Read() error = %v", err)
+		return
+	}
+	bts, err := ioutil.ReadAll(got)
+	if err != nil {
+		t.Errorf("ReadAll() error = %v", err)
+		return
+	}
+	if string(bts) != "test" {
+		t.Errorf("Read() = %s, want %s", bts, "test")
+	}
+}
@@ -0,0 +1,63 @@
+package gostorage
+
+import (
+	"context"
+	"io"
+)
+
+// Copy copies the file/object src to dst.
+// If the driver implements Copier, the native copy of the driver is used.
+// Otherwise the content is read and written again through the process, including
+// the attributes of the file/object if the driver implements Stater and OptionsWriter.
+func Copy(ctx context.Context, d Driver, src, dst string) error {
+	if c, ok := d.(Copier); ok {
+		return c.CopyContext(ctx, src, dst)
+	}
+
+	var value io.Reader
+	if sr, ok := d.(StreamReader); ok {
+		rc, err := sr.ReadStreamContext(ctx, src)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		value = rc
+	} else {
+		r, err := AsDriverContext(d).ReadContext(ctx, src)
+		if err != nil {
+			return err
+		}
+		value = r
+	}
+
+	stater, isStater := d.(Stater)
+	writer, isWriter := d.(OptionsWriter)
+	if !isStater || !isWriter {
+		return AsDriverContext(d).WriteContext(ctx, dst, value)
+	}
+	info, err := stater.StatContext(ctx, src)
+	if err != nil {
+		return err
+	}
+	return writer.WriteWithOptionsContext(ctx, dst, value, WriteOptions{
+		ContentType:        info.ContentType,
+		CacheControl:       info.CacheControl,
+		ContentDisposition: info.ContentDisposition,
+		ContentEncoding:    info.ContentEncoding,
+		Metadata:           info.Metadata,
+	})
+}
+
+// Move moves the file/object src to dst.
+// If the driver implements Mover, the native move of the driver is used.
+// Otherwise src is copied to dst by Copy and deleted afterwards.
+func Move(ctx context.Context, d Driver, src, dst string) error {
+	if m, ok := d.(Mover); ok {
+		return m.MoveContext(ctx, src, dst)
+	}
+	err := Copy(ctx, d, src, dst)
+	if err != nil {
+		return err
+	}
+	return AsDriverContext(d).DeleteContext(ctx, src)
+}
@@ -0,0 +1,93 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		dst     string
+		want    memoryDriver
+		wantErr error
+	}{
+		{
+			name: "copy",
+			src:  "src.txt",
+			dst:  "dst.txt",
+			want: memoryDriver{
+				"src.txt": []byte("test"),
+				"dst.txt": []byte("test"),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "source not found",
+			src:  "missing.txt",
+			dst:  "dst.txt",
+			want: memoryDriver{
+				"src.txt": []byte("test"),
+			},
+			wantErr: ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := memoryDriver{"src.txt": []byte("test")}
+			err := Copy(context.Background(), d, tt.src, tt.dst)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Copy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(d, tt.want) {
+				t.Errorf("Copy() = %v, want %v", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		dst     string
+		want    memoryDriver
+		wantErr error
+	}{
+		{
+			name: "move",
+			src:  "src.txt",
+			dst:  "dst.txt",
+			want: memoryDriver{
+				"dst.txt": []byte("test"),
+			},
+			wantErr: nil,
+		},
+		{
+			name: "source not found",
+			src:  "missing.txt",
+			dst:  "dst.txt",
+			want: memoryDriver{
+				"src.txt": []byte("test"),
+			},
+			wantErr: ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := memoryDriver{"src.txt": []byte("test")}
+			err := Move(context.Background(), d, tt.src, tt.dst)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Move() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(d, tt.want) {
+				t.Errorf("Move() = %v, want %v", d, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DeletePrefixOptions defines the options of a prefix deletion.
+type DeletePrefixOptions struct {
+	// DryRun only returns the keys that would be deleted without deleting anything.
+	DryRun bool
+}
+
+// DeleteMany deletes the files/objects identified by keys and returns the error of
+// every key that could not be deleted. Missing files/objects are not an error.
+// If the driver implements BatchDeleter, the native bulk delete of the driver is used.
+// Otherwise the keys are deleted one after another.
+func DeleteMany(ctx context.Context, d Driver, keys []string) map[string]error {
+	if bd, ok := d.(BatchDeleter); ok {
+		return bd.DeleteManyContext(ctx, keys)
+	}
+	dc := AsDriverContext(d)
+	errs := map[string]error{}
+	for _, key := range keys {
+		err := dc.DeleteContext(ctx, key)
+		if err != nil && !errors.Is(err, ErrNotExist) {
+			errs[key] = err
+		}
+	}
+	return errs
+}
+
+// DeletePrefix deletes all files/objects whose keys start with prefix and returns their keys.
+// An empty prefix is rejected with ErrInvalidKey, so that a storage is never emptied by accident.
+// If the driver implements PrefixDeleter, the native prefix deletion of the driver is used.
+// Otherwise the keys are listed by Lister, or filtered from List if the driver does
+// not implement Lister, and deleted by DeleteMany.
+func DeletePrefix(ctx context.Context, d Driver, prefix string, opts DeletePrefixOptions) ([]string, error) {
+	if pd, ok := d.(PrefixDeleter); ok {
+		return pd.DeletePrefixContext(ctx, prefix, opts)
+	}
+	if prefix == "" {
+		return nil, fmt.Errorf("%w: empty prefix", ErrInvalidKey)
+	}
+
+	keys := []string{}
+	if l, ok := d.(Lister); ok {
+		listOpts := ListOptions{Prefix: prefix}
+		for {
+			res, err := l.ListPageContext(ctx, listOpts)
+			if err != nil {
+				return nil, err
+			}
+			for _, o := range res.Objects {
+				keys = append(keys, o.Key)
+			}
+			if res.NextContinuationToken == "" {
+				break
+			}
+			listOpts.ContinuationToken = res.NextContinuationToken
+		}
+	} else {
+		all, err := AsDriverContext(d).ListContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range all {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if opts.DryRun {
+		return keys, nil
+	}
+
+	errs := DeleteMany(ctx, d, keys)
+	deleted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, failed := errs[key]; !failed {
+			deleted = append(deleted, key)
+		}
+	}
+	if len(errs) > 0 {
+		return deleted, &DeleteError{Errors: errs}
+	}
+	return deleted, nil
+}
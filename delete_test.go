@@ -0,0 +1,137 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDeleteMany(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		keys     []string
+		want     memoryDriver
+		wantErrs map[string]error
+	}{
+		{
+			name: "delete",
+			ctx:  context.Background(),
+			keys: []string{"a.txt", "b.txt"},
+			want: memoryDriver{
+				"c.txt": []byte("c"),
+			},
+			wantErrs: map[string]error{},
+		},
+		{
+			name: "missing keys",
+			ctx:  context.Background(),
+			keys: []string{"a.txt", "missing.txt"},
+			want: memoryDriver{
+				"b.txt": []byte("b"),
+				"c.txt": []byte("c"),
+			},
+			wantErrs: map[string]error{},
+		},
+		{
+			name: "cancelled context",
+			ctx:  cancelled,
+			keys: []string{"a.txt", "b.txt"},
+			want: memoryDriver{
+				"a.txt": []byte("a"),
+				"b.txt": []byte("b"),
+				"c.txt": []byte("c"),
+			},
+			wantErrs: map[string]error{
+				"a.txt": context.Canceled,
+				"b.txt": context.Canceled,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := memoryDriver{
+				"a.txt": []byte("a"),
+				"b.txt": []byte("b"),
+				"c.txt": []byte("c"),
+			}
+			errs := DeleteMany(tt.ctx, d, tt.keys)
+			if len(errs) != len(tt.wantErrs) {
+				t.Errorf("DeleteMany() = %v, want %v", errs, tt.wantErrs)
+			}
+			for key, wantErr := range tt.wantErrs {
+				if !errors.Is(errs[key], wantErr) {
+					t.Errorf("DeleteMany() error of %q = %v, want %v", key, errs[key], wantErr)
+				}
+			}
+			if !reflect.DeepEqual(d, tt.want) {
+				t.Errorf("DeleteMany() = %v, want %v", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeletePrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		opts    DeletePrefixOptions
+		want    []string
+		wantMem memoryDriver
+		wantErr error
+	}{
+		{
+			name:   "delete",
+			prefix: "a/",
+			want:   []string{"a/1.txt", "a/2.txt"},
+			wantMem: memoryDriver{
+				"b/1.txt": []byte("b"),
+			},
+		},
+		{
+			name:   "dry run",
+			prefix: "a/",
+			opts:   DeletePrefixOptions{DryRun: true},
+			want:   []string{"a/1.txt", "a/2.txt"},
+			wantMem: memoryDriver{
+				"a/1.txt": []byte("a"),
+				"a/2.txt": []byte("a"),
+				"b/1.txt": []byte("b"),
+			},
+		},
+		{
+			name:   "empty prefix",
+			prefix: "",
+			want:   nil,
+			wantMem: memoryDriver{
+				"a/1.txt": []byte("a"),
+				"a/2.txt": []byte("a"),
+				"b/1.txt": []byte("b"),
+			},
+			wantErr: ErrInvalidKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := memoryDriver{
+				"a/1.txt": []byte("a"),
+				"a/2.txt": []byte("a"),
+				"b/1.txt": []byte("b"),
+			}
+			got, err := DeletePrefix(context.Background(), d, tt.prefix, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeletePrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeletePrefix() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(d, tt.wantMem) {
+				t.Errorf("DeletePrefix() left %v, want %v", d, tt.wantMem)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package gostorage
 
-import "io"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 // Driver is the interface that must be implemented by a storage driver.
 // It describes the capabilities of a storage driver.
@@ -16,3 +20,193 @@ type Driver interface {
 	// List lists all the files/objects.
 	List() ([]string, error)
 }
+
+// DriverContext is the context aware counterpart of Driver.
+// The context is used to cancel an operation or to bound its duration.
+type DriverContext interface {
+	// ReadContext reads the file/object and returns the content.
+	ReadContext(ctx context.Context, key string) (io.Reader, error)
+	// WriteContext writes the content to the file/object.
+	WriteContext(ctx context.Context, key string, value io.Reader) error
+	// DeleteContext deletes the file/object.
+	DeleteContext(ctx context.Context, key string) error
+	// ExistsContext checks if the file/object exists.
+	ExistsContext(ctx context.Context, key string) (bool, error)
+	// ListContext lists all the files/objects.
+	ListContext(ctx context.Context) ([]string, error)
+}
+
+// StreamReader is implemented by drivers that are able to return the content of a
+// file/object without buffering it in memory.
+//
+// The caller owns the returned io.ReadCloser and must close it once it is done reading,
+// otherwise the underlying file handle or connection is leaked.
+type StreamReader interface {
+	// ReadStream returns a reader streaming the content of the file/object.
+	ReadStream(key string) (io.ReadCloser, error)
+	// ReadStreamContext returns a reader streaming the content of the file/object.
+	// The context applies to the whole lifetime of the returned reader.
+	ReadStreamContext(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// Stater is implemented by drivers that are able to return the metadata of a
+// file/object without reading its content.
+type Stater interface {
+	// Stat returns the metadata of the file/object.
+	Stat(key string) (*ObjectInfo, error)
+	// StatContext returns the metadata of the file/object.
+	StatContext(ctx context.Context, key string) (*ObjectInfo, error)
+}
+
+// OptionsWriter is implemented by drivers that are able to store additional
+// attributes alongside the content of a file/object.
+// The attributes can be read back by Stater.
+type OptionsWriter interface {
+	// WriteWithOptions writes the content and the attributes to the file/object.
+	WriteWithOptions(key string, value io.Reader, opts WriteOptions) error
+	// WriteWithOptionsContext writes the content and the attributes to the file/object.
+	WriteWithOptionsContext(ctx context.Context, key string, value io.Reader, opts WriteOptions) error
+}
+
+// Lister is implemented by drivers that are able to list the files/objects page by page.
+type Lister interface {
+	// ListPage returns a single page of the files/objects selected by opts.
+	ListPage(opts ListOptions) (*ListResult, error)
+	// ListPageContext returns a single page of the files/objects selected by opts.
+	ListPageContext(ctx context.Context, opts ListOptions) (*ListResult, error)
+}
+
+// Copier is implemented by drivers that are able to copy a file/object
+// without transferring its content through the process.
+type Copier interface {
+	// Copy copies the file/object src including its attributes to dst.
+	Copy(src, dst string) error
+	// CopyContext copies the file/object src including its attributes to dst.
+	CopyContext(ctx context.Context, src, dst string) error
+}
+
+// Mover is implemented by drivers that are able to move a file/object
+// without transferring its content through the process.
+type Mover interface {
+	// Move moves the file/object src including its attributes to dst.
+	Move(src, dst string) error
+	// MoveContext moves the file/object src including its attributes to dst.
+	MoveContext(ctx context.Context, src, dst string) error
+}
+
+// RangeReader is implemented by drivers that are able to read a byte range of a file/object.
+//
+// The range is defined by offset and length:
+//   - offset >= 0 and length > 0 reads length bytes starting at offset,
+//     or less if the content ends before.
+//   - offset >= 0 and length < 0 reads from offset until the end of the content.
+//   - offset < 0 and length < 0 reads the last -offset bytes of the content,
+//     or the whole content if it is shorter.
+//
+// ErrInvalidRange is returned for any other combination or if offset is not within the content.
+type RangeReader interface {
+	// ReadRange returns a reader streaming the byte range of the file/object.
+	// The caller must close the returned reader.
+	ReadRange(key string, offset, length int64) (io.ReadCloser, error)
+	// ReadRangeContext returns a reader streaming the byte range of the file/object.
+	// The context applies to the whole lifetime of the returned reader.
+	// The caller must close the returned reader.
+	ReadRangeContext(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// OpenReaderAt returns random access to the content of the file/object.
+	// The caller must close the returned ReadAtCloser.
+	OpenReaderAt(key string) (ReadAtCloser, error)
+	// OpenReaderAtContext returns random access to the content of the file/object.
+	// The context applies to the whole lifetime of the returned ReadAtCloser.
+	// The caller must close the returned ReadAtCloser.
+	OpenReaderAtContext(ctx context.Context, key string) (ReadAtCloser, error)
+}
+
+// ReadAtCloser provides random access to the content of a file/object.
+type ReadAtCloser interface {
+	io.ReaderAt
+	io.Closer
+	// Size returns the size of the content in bytes.
+	Size() int64
+}
+
+// BatchDeleter is implemented by drivers that are able to delete many files/objects at once.
+type BatchDeleter interface {
+	// DeleteMany deletes the files/objects identified by keys.
+	// The returned map contains the error of every key that could not be deleted
+	// and is empty if all keys have been deleted. Missing files/objects are not an error.
+	DeleteMany(keys []string) map[string]error
+	// DeleteManyContext deletes the files/objects identified by keys.
+	// The returned map contains the error of every key that could not be deleted
+	// and is empty if all keys have been deleted. Missing files/objects are not an error.
+	DeleteManyContext(ctx context.Context, keys []string) map[string]error
+}
+
+// PrefixDeleter is implemented by drivers that are able to delete all files/objects below a prefix.
+type PrefixDeleter interface {
+	// DeletePrefix deletes all files/objects whose keys start with prefix and returns their keys.
+	// If some files/objects could not be deleted, the returned error wraps a *DeleteError.
+	DeletePrefix(prefix string, opts DeletePrefixOptions) ([]string, error)
+	// DeletePrefixContext deletes all files/objects whose keys start with prefix and returns their keys.
+	// If some files/objects could not be deleted, the returned error wraps a *DeleteError.
+	DeletePrefixContext(ctx context.Context, prefix string, opts DeletePrefixOptions) ([]string, error)
+}
+
+// URLSigner is implemented by drivers that are able to sign URLs granting temporary
+// access to a file/object, so that clients can download or upload it directly.
+type URLSigner interface {
+	// SignURL returns an URL that allows requests of the HTTP method, GET or PUT,
+	// on the file/object until the expiry has passed.
+	SignURL(method, key string, expiry time.Duration) (string, error)
+}
+
+// Versioner is implemented by drivers that keep the previous versions of a file/object
+// when it is overwritten or deleted.
+type Versioner interface {
+	// ListVersions returns the versions of the file/object identified by key, newest first.
+	ListVersions(key string) ([]ObjectVersion, error)
+	// ListVersionsContext returns the versions of the file/object identified by key, newest first.
+	ListVersionsContext(ctx context.Context, key string) ([]ObjectVersion, error)
+	// ReadVersion returns a reader streaming the content of the version.
+	// The caller must close the returned reader.
+	ReadVersion(key, versionID string) (io.ReadCloser, error)
+	// ReadVersionContext returns a reader streaming the content of the version.
+	// The context applies to the whole lifetime of the returned reader.
+	// The caller must close the returned reader.
+	ReadVersionContext(ctx context.Context, key, versionID string) (io.ReadCloser, error)
+	// DeleteVersion deletes the version permanently. If the latest version is deleted,
+	// the previous version becomes the latest one.
+	DeleteVersion(key, versionID string) error
+	// DeleteVersionContext deletes the version permanently. If the latest version is deleted,
+	// the previous version becomes the latest one.
+	DeleteVersionContext(ctx context.Context, key, versionID string) error
+}
+
+// MultipartUploader is implemented by drivers that upload a file/object in parts.
+// An upload is identified by its Upload handle, which can be persisted to continue
+// the upload after a restart of the process.
+type MultipartUploader interface {
+	// InitiateUpload starts an upload of the file/object with the attributes of opts.
+	// Conditions are not supported and rejected with ErrNotSupported.
+	InitiateUpload(key string, opts WriteOptions) (*Upload, error)
+	// InitiateUploadContext starts an upload of the file/object with the attributes of opts.
+	// Conditions are not supported and rejected with ErrNotSupported.
+	InitiateUploadContext(ctx context.Context, key string, opts WriteOptions) (*Upload, error)
+	// UploadPart uploads the part with the given number, starting at 1. Uploading a part
+	// with the number of a previously uploaded part replaces it.
+	UploadPart(upload *Upload, number int, value io.Reader) (*Part, error)
+	// UploadPartContext uploads the part with the given number, starting at 1. Uploading a part
+	// with the number of a previously uploaded part replaces it.
+	UploadPartContext(ctx context.Context, upload *Upload, number int, value io.Reader) (*Part, error)
+	// ListParts returns the parts uploaded so far, ordered by their number.
+	ListParts(upload *Upload) ([]Part, error)
+	// ListPartsContext returns the parts uploaded so far, ordered by their number.
+	ListPartsContext(ctx context.Context, upload *Upload) ([]Part, error)
+	// CompleteUpload concatenates the parts in the given order to the file/object.
+	CompleteUpload(upload *Upload, parts []Part) error
+	// CompleteUploadContext concatenates the parts in the given order to the file/object.
+	CompleteUploadContext(ctx context.Context, upload *Upload, parts []Part) error
+	// AbortUpload discards the upload including all uploaded parts.
+	AbortUpload(upload *Upload) error
+	// AbortUploadContext discards the upload including all uploaded parts.
+	AbortUploadContext(ctx context.Context, upload *Upload) error
+}
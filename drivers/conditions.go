@@ -0,0 +1,25 @@
+package drivers
+
+import (
+	"errors"
+	"strings"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// errConflictingConditions is returned if a write defines more than one condition.
+var errConflictingConditions = errors.New("IfNotExists and IfMatch can not be combined")
+
+// validateConditions validates the conditions of a conditional write.
+func validateConditions(opts gostorage.WriteOptions) error {
+	if opts.IfNotExists && opts.IfMatch != "" {
+		return errConflictingConditions
+	}
+	return nil
+}
+
+// unquoteETag removes the quotes of an ETag, so that ETags returned by
+// HTTP servers can be compared with the unquoted ETags of gostorage.ObjectInfo.
+func unquoteETag(etag string) string {
+	return strings.Trim(etag, `"`)
+}
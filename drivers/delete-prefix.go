@@ -0,0 +1,63 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// prefixDriver is a driver that is able to list and bulk delete files/objects.
+type prefixDriver interface {
+	gostorage.Lister
+	gostorage.BatchDeleter
+}
+
+// deletePrefix deletes the files/objects below the prefix page by page and returns their keys.
+// The keys that could not be deleted are reported as *gostorage.DeleteError by the named driver.
+func deletePrefix(ctx context.Context, d prefixDriver, driver, prefix string, opts gostorage.DeletePrefixOptions) ([]string, error) {
+	if prefix == "" {
+		return nil, &gostorage.StorageError{
+			Op:     "delete prefix",
+			Driver: driver,
+			Err:    fmt.Errorf("%w: empty prefix", gostorage.ErrInvalidKey),
+		}
+	}
+
+	keys := []string{}
+	errs := map[string]error{}
+	listOpts := gostorage.ListOptions{Prefix: prefix}
+	for {
+		res, err := d.ListPageContext(ctx, listOpts)
+		if err != nil {
+			return keys, err
+		}
+		page := make([]string, 0, len(res.Objects))
+		for _, o := range res.Objects {
+			page = append(page, o.Key)
+		}
+		if !opts.DryRun {
+			for key, err := range d.DeleteManyContext(ctx, page) {
+				errs[key] = err
+			}
+		}
+		for _, key := range page {
+			if _, failed := errs[key]; !failed {
+				keys = append(keys, key)
+			}
+		}
+		if res.NextContinuationToken == "" {
+			break
+		}
+		listOpts.ContinuationToken = res.NextContinuationToken
+	}
+	if len(errs) > 0 {
+		return keys, &gostorage.StorageError{
+			Op:     "delete prefix",
+			Key:    prefix,
+			Driver: driver,
+			Err:    &gostorage.DeleteError{Errors: errs},
+		}
+	}
+	return keys, nil
+}
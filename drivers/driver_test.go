@@ -0,0 +1,1239 @@
+package drivers
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// maxStreamAllocation is the upper bound of memory a streaming read may allocate,
+// independent of the size of the file/object.
+const maxStreamAllocation uint64 = 8 << 20
+
+// allocatedBytes returns the amount of heap memory allocated while running fn.
+func allocatedBytes(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+// listPages follows the continuation tokens of the listing selected by opts and
+// returns the keys of all pages together with the number of pages.
+func listPages(t *testing.T, lister gostorage.Lister, opts gostorage.ListOptions) ([]string, int) {
+	keys := []string{}
+	pages := 0
+	for {
+		res, err := lister.ListPage(opts)
+		if err != nil {
+			t.Errorf("ListPage() error = %v", err)
+			return keys, pages
+		}
+		pages++
+		for _, o := range res.Objects {
+			keys = append(keys, o.Key)
+		}
+		if res.NextContinuationToken == "" {
+			return keys, pages
+		}
+		opts.ContinuationToken = res.NextContinuationToken
+	}
+}
+
+// conformanceDriver is a driver the shared tests are run against.
+type conformanceDriver struct {
+	driver gostorage.Driver
+	// put creates a file/object without using the driver.
+	put func(key string, content []byte)
+	// cleanup removes all files/objects.
+	cleanup func()
+}
+
+// conformanceDrivers returns an empty instance of every driver, so that the shared
+// tests can verify that all drivers behave identically.
+func conformanceDrivers(t *testing.T) map[string]conformanceDriver {
+	const (
+		localPath = "/tmp/test-conformance"
+		bucket    = "conformance-bucket"
+	)
+	svc := s3.New(awsSession)
+	_, _ = svc.CreateBucket(&s3.CreateBucketInput{
+		Bucket: aws.String(bucket),
+	})
+
+	return map[string]conformanceDriver{
+		"local-storage": {
+			driver: NewLocalStorage(localPath),
+			put: func(key string, content []byte) {
+				filePath := path.Join(localPath, key)
+				err := os.MkdirAll(path.Dir(filePath), 0755)
+				if err != nil {
+					t.Errorf("error creating directory: %v", err)
+				}
+				err = ioutil.WriteFile(filePath, content, 0644)
+				if err != nil {
+					t.Errorf("error creating file: %v", err)
+				}
+			},
+			cleanup: func() {
+				err := os.RemoveAll(localPath)
+				if err != nil {
+					t.Errorf("error removing directory: %v", err)
+				}
+				err = os.MkdirAll(localPath, 0755)
+				if err != nil {
+					t.Errorf("error creating directory: %v", err)
+				}
+			},
+		},
+		"s3": {
+			driver: NewS3(bucket, "", svc, awsSession),
+			put: func(key string, content []byte) {
+				_, err := svc.PutObject(&s3.PutObjectInput{
+					Bucket: aws.String(bucket),
+					Key:    aws.String(key),
+					Body:   bytes.NewReader(content),
+				})
+				if err != nil {
+					t.Errorf("PutObject() error = %v", err)
+				}
+			},
+			cleanup: func() {
+				err := svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+					Bucket: aws.String(bucket),
+				}, func(loo *s3.ListObjectsV2Output, lastPage bool) bool {
+					for _, o := range loo.Contents {
+						_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+							Bucket: aws.String(bucket),
+							Key:    o.Key,
+						})
+						if err != nil {
+							t.Errorf("DeleteObject() error = %v", err)
+						}
+					}
+					return true
+				})
+				if err != nil {
+					t.Errorf("ListObjectsV2Pages() error = %v", err)
+				}
+			},
+		},
+		"s3 with path prefix": {
+			driver: NewS3(bucket, "prefix", svc, awsSession),
+			put: func(key string, content []byte) {
+				_, err := svc.PutObject(&s3.PutObjectInput{
+					Bucket: aws.String(bucket),
+					Key:    aws.String("prefix/" + key),
+					Body:   bytes.NewReader(content),
+				})
+				if err != nil {
+					t.Errorf("PutObject() error = %v", err)
+				}
+			},
+			cleanup: func() {
+				err := svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+					Bucket: aws.String(bucket),
+				}, func(loo *s3.ListObjectsV2Output, lastPage bool) bool {
+					for _, o := range loo.Contents {
+						_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+							Bucket: aws.String(bucket),
+							Key:    o.Key,
+						})
+						if err != nil {
+							t.Errorf("DeleteObject() error = %v", err)
+						}
+					}
+					return true
+				})
+				if err != nil {
+					t.Errorf("ListObjectsV2Pages() error = %v", err)
+				}
+			},
+		},
+	}
+}
+
+func TestListPage_delimiter(t *testing.T) {
+	keys := []string{"a.txt", "b.txt", "c-f.txt", "c/d.txt", "c/e.txt", "c/g/h.txt", "c/g/i/j.txt"}
+
+	type want struct {
+		objects        []string
+		commonPrefixes []string
+		pages          int
+	}
+	tests := []struct {
+		name string
+		opts gostorage.ListOptions
+		want want
+	}{
+		{
+			name: "top level",
+			opts: gostorage.ListOptions{Delimiter: "/"},
+			want: want{
+				objects:        []string{"a.txt", "b.txt", "c-f.txt"},
+				commonPrefixes: []string{"c/"},
+				pages:          1,
+			},
+		},
+		{
+			name: "sub directory",
+			opts: gostorage.ListOptions{Prefix: "c/", Delimiter: "/"},
+			want: want{
+				objects:        []string{"c/d.txt", "c/e.txt"},
+				commonPrefixes: []string{"c/g/"},
+				pages:          1,
+			},
+		},
+		{
+			name: "pages of one",
+			opts: gostorage.ListOptions{Delimiter: "/", PageSize: 1},
+			want: want{
+				objects:        []string{"a.txt", "b.txt", "c-f.txt"},
+				commonPrefixes: []string{"c/"},
+				pages:          4,
+			},
+		},
+		{
+			name: "prefix in the middle of a name",
+			opts: gostorage.ListOptions{Prefix: "c", Delimiter: "/"},
+			want: want{
+				objects:        []string{"c-f.txt"},
+				commonPrefixes: []string{"c/"},
+				pages:          1,
+			},
+		},
+		{
+			name: "delimiter other than slash",
+			opts: gostorage.ListOptions{Delimiter: "."},
+			want: want{
+				objects:        []string{},
+				commonPrefixes: []string{"a.", "b.", "c-f.", "c/d.", "c/e.", "c/g/h.", "c/g/i/j."},
+				pages:          1,
+			},
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		cd.cleanup()
+		for _, key := range keys {
+			cd.put(key, []byte("test"))
+		}
+		if name == "local-storage" {
+			// directories without files are not common prefixes
+			err := os.MkdirAll("/tmp/test-conformance/empty/dir", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+		}
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				lister := cd.driver.(gostorage.Lister)
+				got := want{
+					objects:        []string{},
+					commonPrefixes: []string{},
+				}
+				opts := tt.opts
+				for {
+					res, err := lister.ListPage(opts)
+					if err != nil {
+						t.Errorf("ListPage() error = %v", err)
+						return
+					}
+					got.pages++
+					for _, o := range res.Objects {
+						got.objects = append(got.objects, o.Key)
+					}
+					got.commonPrefixes = append(got.commonPrefixes, res.CommonPrefixes...)
+					if res.NextContinuationToken == "" {
+						break
+					}
+					opts.ContinuationToken = res.NextContinuationToken
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ListPage() = %+v, want %+v", got, tt.want)
+				}
+			})
+		}
+		cd.cleanup()
+	}
+}
+
+func TestDriver_notExistError(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(d gostorage.Driver) error
+	}{
+		{
+			name: "Read",
+			call: func(d gostorage.Driver) error {
+				_, err := d.Read("missing.txt")
+				return err
+			},
+		},
+		{
+			name: "ReadStream",
+			call: func(d gostorage.Driver) error {
+				_, err := d.(gostorage.StreamReader).ReadStream("missing.txt")
+				return err
+			},
+		},
+		{
+			name: "Stat",
+			call: func(d gostorage.Driver) error {
+				_, err := d.(gostorage.Stater).Stat("missing.txt")
+				return err
+			},
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		cd.cleanup()
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				err := tt.call(cd.driver)
+				if !errors.Is(err, gostorage.ErrNotExist) {
+					t.Errorf("%s() error = %v, want %v", tt.name, err, gostorage.ErrNotExist)
+				}
+				var serr *gostorage.StorageError
+				if !errors.As(err, &serr) {
+					t.Errorf("%s() error = %T, want %T", tt.name, err, serr)
+					return
+				}
+				if serr.Driver != name || serr.Key != "missing.txt" {
+					t.Errorf("%s() error = %+v, want driver %q and key %q", tt.name, serr, name, "missing.txt")
+				}
+			})
+		}
+	}
+}
+
+func TestDriver_Exists(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want bool
+	}{
+		{
+			name: "missing",
+			key:  "missing.txt",
+			want: false,
+		},
+		{
+			name: "empty",
+			key:  "empty.txt",
+			want: true,
+		},
+		{
+			name: "not empty",
+			key:  "test.txt",
+			want: true,
+		},
+		{
+			name: "directory",
+			key:  "dir",
+			want: false,
+		},
+		{
+			name: "below a file",
+			key:  "test.txt/missing.txt",
+			want: false,
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		cd.cleanup()
+		cd.put("empty.txt", []byte{})
+		cd.put("test.txt", []byte("test"))
+		cd.put("dir/test.txt", []byte("test"))
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				got, err := cd.driver.Exists(tt.key)
+				if err != nil {
+					t.Errorf("Exists() error = %v, want nil", err)
+					return
+				}
+				if got != tt.want {
+					t.Errorf("Exists() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+		cd.cleanup()
+	}
+}
+
+func TestDriver_ReadRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		offset  int64
+		length  int64
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:   "range",
+			key:    "test.txt",
+			offset: 2,
+			length: 3,
+			want:   []byte("234"),
+		},
+		{
+			name:   "range beyond end",
+			key:    "test.txt",
+			offset: 8,
+			length: 5,
+			want:   []byte("89"),
+		},
+		{
+			name:   "until end",
+			key:    "test.txt",
+			offset: 6,
+			length: -1,
+			want:   []byte("6789"),
+		},
+		{
+			name:   "suffix",
+			key:    "test.txt",
+			offset: -3,
+			length: -1,
+			want:   []byte("789"),
+		},
+		{
+			name:    "offset beyond end",
+			key:     "test.txt",
+			offset:  10,
+			length:  1,
+			wantErr: gostorage.ErrInvalidRange,
+		},
+		{
+			name:    "zero length",
+			key:     "test.txt",
+			offset:  0,
+			length:  0,
+			wantErr: gostorage.ErrInvalidRange,
+		},
+		{
+			name:    "missing",
+			key:     "missing.txt",
+			offset:  0,
+			length:  1,
+			wantErr: gostorage.ErrNotExist,
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		cd.cleanup()
+		cd.put("test.txt", []byte("0123456789"))
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				rc, err := cd.driver.(gostorage.RangeReader).ReadRange(tt.key, tt.offset, tt.length)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ReadRange() error = %v, wantErr %v", err, tt.wantErr)
+					return
+				}
+				if err != nil {
+					return
+				}
+				defer rc.Close()
+				got, err := ioutil.ReadAll(rc)
+				if err != nil {
+					t.Errorf("ReadAll() error = %v", err)
+					return
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ReadRange() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+		cd.cleanup()
+	}
+}
+
+func TestDriver_OpenReaderAt(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  int64
+		size    int
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:   "within content",
+			offset: 2,
+			size:   3,
+			want:   []byte("234"),
+		},
+		{
+			name:    "beyond end",
+			offset:  8,
+			size:    5,
+			want:    []byte("89"),
+			wantErr: io.EOF,
+		},
+		{
+			name:    "at end",
+			offset:  10,
+			size:    1,
+			want:    []byte{},
+			wantErr: io.EOF,
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		cd.cleanup()
+		cd.put("test.txt", []byte("0123456789"))
+
+		ra, err := cd.driver.(gostorage.RangeReader).OpenReaderAt("test.txt")
+		if err != nil {
+			t.Errorf("%s: OpenReaderAt() error = %v", name, err)
+			cd.cleanup()
+			continue
+		}
+		if ra.Size() != 10 {
+			t.Errorf("%s: Size() = %v, want %v", name, ra.Size(), 10)
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				p := make([]byte, tt.size)
+				n, err := ra.ReadAt(p, tt.offset)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ReadAt() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !reflect.DeepEqual(p[:n], tt.want) {
+					t.Errorf("ReadAt() = %q, want %q", p[:n], tt.want)
+				}
+			})
+		}
+		err = ra.Close()
+		if err != nil {
+			t.Errorf("%s: Close() error = %v", name, err)
+		}
+
+		_, err = cd.driver.(gostorage.RangeReader).OpenReaderAt("missing.txt")
+		if !errors.Is(err, gostorage.ErrNotExist) {
+			t.Errorf("%s: OpenReaderAt() error = %v, want %v", name, err, gostorage.ErrNotExist)
+		}
+		cd.cleanup()
+	}
+}
+
+func TestDriver_conditionalWrite(t *testing.T) {
+	etag := fmt.Sprintf("%x", md5.Sum([]byte("test")))
+	tests := []struct {
+		name    string
+		key     string
+		opts    gostorage.WriteOptions
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "create missing",
+			key:  "missing.txt",
+			opts: gostorage.WriteOptions{IfNotExists: true},
+			want: []byte("changed"),
+		},
+		{
+			name:    "create existing",
+			key:     "test.txt",
+			opts:    gostorage.WriteOptions{IfNotExists: true},
+			want:    []byte("test"),
+			wantErr: gostorage.ErrPreconditionFailed,
+		},
+		{
+			name: "replace matching",
+			key:  "test.txt",
+			opts: gostorage.WriteOptions{IfMatch: etag},
+			want: []byte("changed"),
+		},
+		{
+			name: "replace matching quoted",
+			key:  "test.txt",
+			opts: gostorage.WriteOptions{IfMatch: `"` + etag + `"`},
+			want: []byte("changed"),
+		},
+		{
+			name:    "replace not matching",
+			key:     "test.txt",
+			opts:    gostorage.WriteOptions{IfMatch: "0123456789abcdef0123456789abcdef"},
+			want:    []byte("test"),
+			wantErr: gostorage.ErrPreconditionFailed,
+		},
+		{
+			name:    "replace missing",
+			key:     "missing.txt",
+			opts:    gostorage.WriteOptions{IfMatch: etag},
+			want:    nil,
+			wantErr: gostorage.ErrPreconditionFailed,
+		},
+		{
+			name:    "conflicting conditions",
+			key:     "test.txt",
+			opts:    gostorage.WriteOptions{IfNotExists: true, IfMatch: etag},
+			want:    []byte("test"),
+			wantErr: errConflictingConditions,
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				cd.cleanup()
+				defer cd.cleanup()
+				cd.put("test.txt", []byte("test"))
+
+				// the content type is set, so that the content is not sniffed
+				tt.opts.ContentType = "text/plain"
+				err := cd.driver.(gostorage.OptionsWriter).WriteWithOptions(tt.key, bytes.NewReader([]byte("changed")), tt.opts)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("WriteWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				exists, err := cd.driver.Exists(tt.key)
+				if err != nil {
+					t.Errorf("Exists() error = %v", err)
+					return
+				}
+				if exists != (tt.want != nil) {
+					t.Errorf("Exists() = %v, want %v", exists, tt.want != nil)
+					return
+				}
+				if !exists {
+					return
+				}
+				r, err := cd.driver.Read(tt.key)
+				if err != nil {
+					t.Errorf("Read() error = %v", err)
+					return
+				}
+				got, err := ioutil.ReadAll(r)
+				if err != nil {
+					t.Errorf("ReadAll() error = %v", err)
+					return
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Read() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestDriver_conditionalWrite_concurrent(t *testing.T) {
+	const writers = 10
+	etag := fmt.Sprintf("%x", md5.Sum([]byte("test")))
+	tests := []struct {
+		name string
+		key  string
+		opts gostorage.WriteOptions
+	}{
+		{
+			name: "create",
+			key:  "missing.txt",
+			opts: gostorage.WriteOptions{ContentType: "text/plain", IfNotExists: true},
+		},
+		{
+			name: "replace",
+			key:  "test.txt",
+			opts: gostorage.WriteOptions{ContentType: "text/plain", IfMatch: etag},
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				cd.cleanup()
+				defer cd.cleanup()
+				cd.put("test.txt", []byte("test"))
+
+				errs := make(chan error, writers)
+				for i := 0; i < writers; i++ {
+					go func(i int) {
+						content := fmt.Sprintf("writer %d", i)
+						errs <- cd.driver.(gostorage.OptionsWriter).WriteWithOptions(tt.key, strings.NewReader(content), tt.opts)
+					}(i)
+				}
+				succeeded := 0
+				for i := 0; i < writers; i++ {
+					err := <-errs
+					switch {
+					case err == nil:
+						succeeded++
+					case !errors.Is(err, gostorage.ErrPreconditionFailed):
+						t.Errorf("WriteWithOptions() error = %v, want %v", err, gostorage.ErrPreconditionFailed)
+					}
+				}
+				if succeeded != 1 {
+					t.Errorf("WriteWithOptions() succeeded %d times, want 1", succeeded)
+				}
+			})
+		}
+	}
+}
+
+func TestDriver_DeleteMany(t *testing.T) {
+	many := []string{}
+	for i := 0; i < 50; i++ {
+		many = append(many, fmt.Sprintf("many/%02d.txt", i))
+	}
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{
+			name: "delete",
+			keys: []string{"a.txt", "dir/c.txt"},
+			want: []string{"b.txt"},
+		},
+		{
+			name: "missing keys",
+			keys: []string{"a.txt", "missing.txt", "dir/missing.txt"},
+			want: []string{"b.txt", "dir/c.txt"},
+		},
+		{
+			name: "many keys",
+			keys: many,
+			want: []string{"a.txt", "b.txt", "dir/c.txt"},
+		},
+		{
+			name: "no keys",
+			keys: []string{},
+			want: []string{"a.txt", "b.txt", "dir/c.txt"},
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				cd.cleanup()
+				defer cd.cleanup()
+				for _, key := range append([]string{"a.txt", "b.txt", "dir/c.txt"}, tt.keys...) {
+					if !strings.Contains(key, "missing") {
+						cd.put(key, []byte("test"))
+					}
+				}
+
+				errs := cd.driver.(gostorage.BatchDeleter).DeleteMany(tt.keys)
+				if len(errs) != 0 {
+					t.Errorf("DeleteMany() = %v, want no errors", errs)
+				}
+				got, _ := listPages(t, cd.driver.(gostorage.Lister), gostorage.ListOptions{})
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("DeleteMany() left %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestDriver_DeletePrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		opts     gostorage.DeletePrefixOptions
+		want     []string
+		wantLeft []string
+		wantErr  error
+	}{
+		{
+			name:     "directory",
+			prefix:   "tenant-42/",
+			want:     []string{"tenant-42/a.txt", "tenant-42/dir/b.txt"},
+			wantLeft: []string{"other.txt", "tenant-421.txt"},
+		},
+		{
+			name:     "partial name",
+			prefix:   "tenant-42",
+			want:     []string{"tenant-42/a.txt", "tenant-42/dir/b.txt", "tenant-421.txt"},
+			wantLeft: []string{"other.txt"},
+		},
+		{
+			name:     "dry run",
+			prefix:   "tenant-42/",
+			opts:     gostorage.DeletePrefixOptions{DryRun: true},
+			want:     []string{"tenant-42/a.txt", "tenant-42/dir/b.txt"},
+			wantLeft: []string{"other.txt", "tenant-42/a.txt", "tenant-42/dir/b.txt", "tenant-421.txt"},
+		},
+		{
+			name:     "no match",
+			prefix:   "missing/",
+			want:     []string{},
+			wantLeft: []string{"other.txt", "tenant-42/a.txt", "tenant-42/dir/b.txt", "tenant-421.txt"},
+		},
+		{
+			name:     "empty prefix",
+			prefix:   "",
+			want:     nil,
+			wantLeft: []string{"other.txt", "tenant-42/a.txt", "tenant-42/dir/b.txt", "tenant-421.txt"},
+			wantErr:  gostorage.ErrInvalidKey,
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				cd.cleanup()
+				defer cd.cleanup()
+				for _, key := range []string{"other.txt", "tenant-42/a.txt", "tenant-42/dir/b.txt", "tenant-421.txt"} {
+					cd.put(key, []byte("test"))
+				}
+
+				got, err := cd.driver.(gostorage.PrefixDeleter).DeletePrefix(tt.prefix, tt.opts)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("DeletePrefix() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("DeletePrefix() = %v, want %v", got, tt.want)
+				}
+				left, _ := listPages(t, cd.driver.(gostorage.Lister), gostorage.ListOptions{})
+				if !reflect.DeepEqual(left, tt.wantLeft) {
+					t.Errorf("DeletePrefix() left %v, want %v", left, tt.wantLeft)
+				}
+			})
+		}
+	}
+}
+
+func TestDriver_Capabilities(t *testing.T) {
+	// the capabilities that are bound to an optional interface
+	interfaces := []struct {
+		capability gostorage.Capability
+		implements func(d gostorage.Driver) bool
+	}{
+		{gostorage.CapabilityContext, func(d gostorage.Driver) bool { _, ok := d.(gostorage.DriverContext); return ok }},
+		{gostorage.CapabilityStreamRead, func(d gostorage.Driver) bool { _, ok := d.(gostorage.StreamReader); return ok }},
+		{gostorage.CapabilityRangeRead, func(d gostorage.Driver) bool { _, ok := d.(gostorage.RangeReader); return ok }},
+		{gostorage.CapabilityStat, func(d gostorage.Driver) bool { _, ok := d.(gostorage.Stater); return ok }},
+		{gostorage.CapabilityWriteOptions, func(d gostorage.Driver) bool { _, ok := d.(gostorage.OptionsWriter); return ok }},
+		{gostorage.CapabilityHierarchicalList, func(d gostorage.Driver) bool { _, ok := d.(gostorage.Lister); return ok }},
+		{gostorage.CapabilityCopy, func(d gostorage.Driver) bool { _, ok := d.(gostorage.Copier); return ok }},
+		{gostorage.CapabilityMove, func(d gostorage.Driver) bool { _, ok := d.(gostorage.Mover); return ok }},
+		{gostorage.CapabilityBatchDelete, func(d gostorage.Driver) bool { _, ok := d.(gostorage.BatchDeleter); return ok }},
+		{gostorage.CapabilityPrefixDelete, func(d gostorage.Driver) bool { _, ok := d.(gostorage.PrefixDeleter); return ok }},
+		{gostorage.CapabilityMultipartUpload, func(d gostorage.Driver) bool { _, ok := d.(gostorage.MultipartUploader); return ok }},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		t.Run(name, func(t *testing.T) {
+			got := gostorage.CapabilitiesOf(cd.driver)
+			if !got.Has(gostorage.CapabilityConditionalWrite) {
+				t.Errorf("CapabilitiesOf() = %v, want %v", got, gostorage.CapabilityConditionalWrite)
+			}
+			for _, i := range interfaces {
+				if got.Has(i.capability) != i.implements(cd.driver) {
+					t.Errorf("CapabilitiesOf() reports %v = %v, but the interface is implemented = %v",
+						i.capability, got.Has(i.capability), i.implements(cd.driver))
+				}
+			}
+		})
+	}
+}
+
+func TestDriver_FS(t *testing.T) {
+	files := []string{"fs/a.txt", "fs/dir/b.txt", "fs/dir/sub/c.txt", "fs/dir2/d.txt"}
+	for name, cd := range conformanceDrivers(t) {
+		t.Run(name, func(t *testing.T) {
+			cd.cleanup()
+			defer cd.cleanup()
+			cd.put("outside.txt", []byte("outside"))
+			for _, file := range files {
+				cd.put(file, []byte(file))
+			}
+
+			fsys, err := fs.Sub(gostorage.NewFS(cd.driver), "fs")
+			if err != nil {
+				t.Errorf("fs.Sub() error = %v", err)
+				return
+			}
+			err = fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/sub/c.txt", "dir2/d.txt")
+			if err != nil {
+				t.Errorf("fstest.TestFS() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDriver_Handler(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "get",
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+		},
+		{
+			name:       "range",
+			header:     map[string]string{"Range": "bytes=2-4"},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   "234",
+		},
+		{
+			name:       "if none match",
+			header:     map[string]string{"If-None-Match": fmt.Sprintf(`"%x"`, md5.Sum([]byte("0123456789")))},
+			wantStatus: http.StatusNotModified,
+			wantBody:   "",
+		},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		cd.cleanup()
+		cd.put("dir/test.txt", []byte("0123456789"))
+		server := httptest.NewServer(gostorage.NewHandler(cd.driver))
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				req, err := http.NewRequest(http.MethodGet, server.URL+"/dir/test.txt", nil)
+				if err != nil {
+					t.Errorf("http.NewRequest() error = %v", err)
+					return
+				}
+				for k, v := range tt.header {
+					req.Header.Set(k, v)
+				}
+				res, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Errorf("Do() error = %v", err)
+					return
+				}
+				defer res.Body.Close()
+				if res.StatusCode != tt.wantStatus {
+					t.Errorf("status = %v, want %v", res.StatusCode, tt.wantStatus)
+				}
+				body, err := ioutil.ReadAll(res.Body)
+				if err != nil {
+					t.Errorf("ReadAll() error = %v", err)
+					return
+				}
+				if string(body) != tt.wantBody {
+					t.Errorf("body = %q, want %q", body, tt.wantBody)
+				}
+			})
+		}
+		server.Close()
+		cd.cleanup()
+	}
+}
+
+// versioningDriver is a driver keeping the previous versions of the files/objects.
+type versioningDriver struct {
+	driver gostorage.Driver
+	// cleanup removes all files/objects including their versions.
+	cleanup func()
+}
+
+// versioningDrivers returns an empty instance of every driver with versioning enabled.
+func versioningDrivers(t *testing.T) map[string]versioningDriver {
+	const (
+		localPath = "/tmp/test-versioning"
+		bucket    = "versioning-bucket"
+	)
+	svc := s3.New(awsSession)
+	_, _ = svc.CreateBucket(&s3.CreateBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	_, err := svc.PutBucketVersioning(&s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucket),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(s3.BucketVersioningStatusEnabled),
+		},
+	})
+	if err != nil {
+		t.Errorf("PutBucketVersioning() error = %v", err)
+	}
+
+	return map[string]versioningDriver{
+		"local-storage": {
+			driver: &LocalStorage{Path: localPath, Versioning: true},
+			cleanup: func() {
+				err := os.RemoveAll(localPath)
+				if err != nil {
+					t.Errorf("error removing directory: %v", err)
+				}
+				err = os.MkdirAll(localPath, 0755)
+				if err != nil {
+					t.Errorf("error creating directory: %v", err)
+				}
+			},
+		},
+		"s3": {
+			driver: NewS3(bucket, "", svc, awsSession),
+			cleanup: func() {
+				err := svc.ListObjectVersionsPages(&s3.ListObjectVersionsInput{
+					Bucket: aws.String(bucket),
+				}, func(lovo *s3.ListObjectVersionsOutput, lastPage bool) bool {
+					objects := []*s3.ObjectIdentifier{}
+					for _, v := range lovo.Versions {
+						objects = append(objects, &s3.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+					}
+					for _, m := range lovo.DeleteMarkers {
+						objects = append(objects, &s3.ObjectIdentifier{Key: m.Key, VersionId: m.VersionId})
+					}
+					for _, o := range objects {
+						_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+							Bucket:    aws.String(bucket),
+							Key:       o.Key,
+							VersionId: o.VersionId,
+						})
+						if err != nil {
+							t.Errorf("DeleteObject() error = %v", err)
+						}
+					}
+					return true
+				})
+				if err != nil {
+					t.Errorf("ListObjectVersionsPages() error = %v", err)
+				}
+			},
+		},
+	}
+}
+
+func TestDriver_Versioning(t *testing.T) {
+	const key = "dir/doc.txt"
+	opts := gostorage.WriteOptions{ContentType: "text/plain"}
+
+	// readVersion returns the content of the version.
+	readVersion := func(t *testing.T, v gostorage.Versioner, versionID string) string {
+		rc, err := v.ReadVersion(key, versionID)
+		if err != nil {
+			t.Errorf("ReadVersion(%q) error = %v", versionID, err)
+			return ""
+		}
+		defer rc.Close()
+		bts, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Errorf("ReadVersion(%q) read error = %v", versionID, err)
+		}
+		return string(bts)
+	}
+	// listVersions returns the versions of the key and verifies their order.
+	listVersions := func(t *testing.T, v gostorage.Versioner, want int) []gostorage.ObjectVersion {
+		versions, err := v.ListVersions(key)
+		if err != nil {
+			t.Errorf("ListVersions() error = %v", err)
+			return nil
+		}
+		if len(versions) != want {
+			t.Errorf("ListVersions() = %+v, want %d versions", versions, want)
+			return nil
+		}
+		for i, version := range versions {
+			if version.Key != key || version.IsLatest != (i == 0) {
+				t.Errorf("ListVersions()[%d] = %+v, want key %q and latest %v", i, version, key, i == 0)
+			}
+		}
+		return versions
+	}
+
+	for name, vd := range versioningDrivers(t) {
+		t.Run(name, func(t *testing.T) {
+			vd.cleanup()
+			defer vd.cleanup()
+			if !gostorage.CapabilitiesOf(vd.driver).Has(gostorage.CapabilityVersioning) {
+				t.Errorf("CapabilitiesOf() = %v, want %v", gostorage.CapabilitiesOf(vd.driver), gostorage.CapabilityVersioning)
+			}
+			v := vd.driver.(gostorage.Versioner)
+			w := vd.driver.(gostorage.OptionsWriter)
+			for _, content := range []string{"first", "second"} {
+				err := w.WriteWithOptions(key, strings.NewReader(content), opts)
+				if err != nil {
+					t.Fatalf("WriteWithOptions() error = %v", err)
+				}
+			}
+			// a key starting with the key must not be listed
+			err := w.WriteWithOptions(key+".bak", strings.NewReader("backup"), opts)
+			if err != nil {
+				t.Fatalf("WriteWithOptions() error = %v", err)
+			}
+
+			versions := listVersions(t, v, 2)
+			if versions == nil {
+				return
+			}
+			if got := readVersion(t, v, versions[1].VersionID); got != "first" {
+				t.Errorf("ReadVersion() = %q, want %q", got, "first")
+			}
+			if versions[0].Size != int64(len("second")) || versions[0].ETag != fmt.Sprintf("%x", md5.Sum([]byte("second"))) {
+				t.Errorf("ListVersions()[0] = %+v, want size and etag of %q", versions[0], "second")
+			}
+			info, err := vd.driver.(gostorage.Stater).Stat(key)
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if info.VersionID != versions[0].VersionID {
+				t.Errorf("Stat().VersionID = %q, want %q", info.VersionID, versions[0].VersionID)
+			}
+
+			// deleting the key adds a delete marker as latest version
+			err = vd.driver.Delete(key)
+			if err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			_, err = vd.driver.Read(key)
+			if !errors.Is(err, gostorage.ErrNotExist) {
+				t.Errorf("Read() error = %v, want %v", err, gostorage.ErrNotExist)
+			}
+			deleted := listVersions(t, v, 3)
+			if deleted == nil {
+				return
+			}
+			if !deleted[0].DeleteMarker || deleted[1].VersionID != versions[0].VersionID {
+				t.Errorf("ListVersions() = %+v, want a delete marker before %+v", deleted, versions)
+			}
+
+			// deleting the delete marker and the latest version restores the previous ones
+			err = v.DeleteVersion(key, deleted[0].VersionID)
+			if err != nil {
+				t.Fatalf("DeleteVersion() error = %v", err)
+			}
+			if got := readString(t, vd.driver, key); got != "second" {
+				t.Errorf("Read() = %q, want %q", got, "second")
+			}
+			err = v.DeleteVersion(key, versions[0].VersionID)
+			if err != nil {
+				t.Fatalf("DeleteVersion() error = %v", err)
+			}
+			if got := readString(t, vd.driver, key); got != "first" {
+				t.Errorf("Read() = %q, want %q", got, "first")
+			}
+			remaining := listVersions(t, v, 1)
+			if remaining != nil && remaining[0].VersionID != versions[1].VersionID {
+				t.Errorf("ListVersions() = %+v, want %q", remaining, versions[1].VersionID)
+			}
+
+			_, err = v.ListVersions("missing.txt")
+			if !errors.Is(err, gostorage.ErrNotExist) {
+				t.Errorf("ListVersions() error = %v, want %v", err, gostorage.ErrNotExist)
+			}
+		})
+	}
+}
+
+// readString returns the content of the file/object identified by key.
+func readString(t *testing.T, d gostorage.Driver, key string) string {
+	r, err := d.Read(key)
+	if err != nil {
+		t.Errorf("Read() error = %v", err)
+		return ""
+	}
+	bts, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Errorf("Read() read error = %v", err)
+	}
+	return string(bts)
+}
+
+func TestDriver_MultipartUpload(t *testing.T) {
+	// all parts but the last one must be at least 5 MiB for S3
+	first := bytes.Repeat([]byte("a"), 5<<20)
+	second := []byte("second part")
+	opts := gostorage.WriteOptions{
+		ContentType: "application/octet-stream",
+		Metadata:    map[string]string{"Source": "multipart"},
+	}
+	for name, cd := range conformanceDrivers(t) {
+		t.Run(name, func(t *testing.T) {
+			cd.cleanup()
+			defer cd.cleanup()
+			mu := cd.driver.(gostorage.MultipartUploader)
+
+			upload, err := mu.InitiateUpload("multipart.bin", opts)
+			if err != nil {
+				t.Fatalf("InitiateUpload() error = %v", err)
+			}
+			// the upload is continued from its persisted handle
+			bts, err := json.Marshal(upload)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			resumed := &gostorage.Upload{}
+			err = json.Unmarshal(bts, resumed)
+			if err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			for number, content := range map[int][]byte{2: second, 1: first} {
+				part, err := mu.UploadPart(resumed, number, bytes.NewReader(content))
+				if err != nil {
+					t.Fatalf("UploadPart(%d) error = %v", number, err)
+				}
+				if part.Size != int64(len(content)) || part.ETag != fmt.Sprintf("%x", md5.Sum(content)) {
+					t.Errorf("UploadPart(%d) = %+v, want size and etag of the content", number, part)
+				}
+			}
+			parts, err := mu.ListParts(resumed)
+			if err != nil {
+				t.Fatalf("ListParts() error = %v", err)
+			}
+			if len(parts) != 2 || parts[0].Number != 1 || parts[1].Number != 2 || parts[1].Size != int64(len(second)) {
+				t.Errorf("ListParts() = %+v, want parts 1 and 2", parts)
+			}
+
+			err = mu.CompleteUpload(resumed, parts)
+			if err != nil {
+				t.Fatalf("CompleteUpload() error = %v", err)
+			}
+			got := readString(t, cd.driver, "multipart.bin")
+			if got != string(first)+string(second) {
+				t.Errorf("CompleteUpload() wrote %d bytes, want %d", len(got), len(first)+len(second))
+			}
+			info, err := cd.driver.(gostorage.Stater).Stat("multipart.bin")
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if info.Metadata["source"] != "multipart" {
+				t.Errorf("Stat().Metadata = %v, want the metadata of the upload", info.Metadata)
+			}
+		})
+	}
+}
+
+func TestDriver_MultipartUpload_abort(t *testing.T) {
+	for name, cd := range conformanceDrivers(t) {
+		t.Run(name, func(t *testing.T) {
+			cd.cleanup()
+			defer cd.cleanup()
+			mu := cd.driver.(gostorage.MultipartUploader)
+
+			_, err := mu.InitiateUpload("multipart.bin", gostorage.WriteOptions{IfNotExists: true})
+			if !errors.Is(err, gostorage.ErrNotSupported) {
+				t.Errorf("InitiateUpload() error = %v, want %v", err, gostorage.ErrNotSupported)
+			}
+			upload, err := mu.InitiateUpload("multipart.bin", gostorage.WriteOptions{})
+			if err != nil {
+				t.Fatalf("InitiateUpload() error = %v", err)
+			}
+			_, err = mu.UploadPart(upload, 1, strings.NewReader("part"))
+			if err != nil {
+				t.Fatalf("UploadPart() error = %v", err)
+			}
+			err = mu.AbortUpload(upload)
+			if err != nil {
+				t.Fatalf("AbortUpload() error = %v", err)
+			}
+			_, err = mu.ListParts(upload)
+			if !errors.Is(err, gostorage.ErrNotExist) {
+				t.Errorf("ListParts() error = %v, want %v", err, gostorage.ErrNotExist)
+			}
+			exists, err := cd.driver.Exists("multipart.bin")
+			if err != nil || exists {
+				t.Errorf("Exists() = %v, %v, want the aborted upload not to exist", exists, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+const (
+	// localStorageDriverName is the name of the LocalStorage driver used in errors.
+	localStorageDriverName = "local-storage"
+	// s3DriverName is the name of the S3 driver used in errors.
+	s3DriverName = "s3"
+)
+
+// mappedError wraps a native error of a driver and additionally matches
+// the sentinel error it has been mapped to.
+type mappedError struct {
+	sentinel error
+	err      error
+}
+
+func (e *mappedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *mappedError) Unwrap() error {
+	return e.err
+}
+
+func (e *mappedError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// localStorageError returns the error of a LocalStorage operation.
+// Most errors of the os package already match the sentinel errors of gostorage,
+// the remaining ones are mapped by mapLocalStorageError.
+func localStorageError(op, key string, err error) error {
+	return &gostorage.StorageError{
+		Op:     op,
+		Key:    key,
+		Driver: localStorageDriverName,
+		Err:    mapLocalStorageError(err),
+	}
+}
+
+// mapLocalStorageError maps the errors of the os package that do not match a sentinel
+// error of gostorage. A parent of the path being a file, e.g. for "file.txt/x", means
+// that the file does not exist. Other errors are returned as is.
+func mapLocalStorageError(err error) error {
+	if errors.Is(err, syscall.ENOTDIR) && !errors.Is(err, gostorage.ErrNotExist) {
+		return &mappedError{
+			sentinel: gostorage.ErrNotExist,
+			err:      err,
+		}
+	}
+	return err
+}
+
+// s3Error returns the error of a S3 operation with the aws error mapped
+// onto the sentinel errors of gostorage.
+func s3Error(op, key string, err error) error {
+	return &gostorage.StorageError{
+		Op:     op,
+		Key:    key,
+		Driver: s3DriverName,
+		Err:    mapS3Error(err),
+	}
+}
+
+// mapS3Error maps the error returned by the aws sdk onto the sentinel errors of gostorage.
+// Errors that can not be mapped are returned as is.
+func mapS3Error(err error) error {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return err
+	}
+
+	var sentinel error
+	switch aerr.Code() {
+	case s3.ErrCodeNoSuchKey, s3.ErrCodeNoSuchBucket, s3.ErrCodeNoSuchUpload, "NoSuchVersion", "NotFound":
+		sentinel = gostorage.ErrNotExist
+	case "AccessDenied", "Forbidden":
+		sentinel = gostorage.ErrPermission
+	case "PreconditionFailed", "ConditionalRequestConflict":
+		sentinel = gostorage.ErrPreconditionFailed
+	case "KeyTooLongError", "InvalidObjectName":
+		sentinel = gostorage.ErrInvalidKey
+	case "InvalidRange":
+		sentinel = gostorage.ErrInvalidRange
+	}
+	// a cancelled or expired context is reported as the original error of the request
+	if orig := aerr.OrigErr(); sentinel == nil && orig != nil {
+		switch {
+		case errors.Is(orig, context.Canceled):
+			sentinel = context.Canceled
+		case errors.Is(orig, context.DeadlineExceeded):
+			sentinel = context.DeadlineExceeded
+		}
+	}
+	if sentinel == nil {
+		var rerr awserr.RequestFailure
+		if errors.As(err, &rerr) {
+			switch rerr.StatusCode() {
+			case 404:
+				sentinel = gostorage.ErrNotExist
+			case 403:
+				sentinel = gostorage.ErrPermission
+			case 412:
+				sentinel = gostorage.ErrPreconditionFailed
+			case 416:
+				sentinel = gostorage.ErrInvalidRange
+			}
+		}
+	}
+	if sentinel == nil {
+		return err
+	}
+	return &mappedError{
+		sentinel: sentinel,
+		err:      err,
+	}
+}
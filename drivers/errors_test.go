@@ -0,0 +1,73 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+func Test_mapS3Error(t *testing.T) {
+	otherErr := errors.New("other")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "no such key",
+			err:  awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil),
+			want: gostorage.ErrNotExist,
+		},
+		{
+			name: "no such version",
+			err:  awserr.NewRequestFailure(awserr.New("NoSuchVersion", "The specified version does not exist.", nil), 404, "id"),
+			want: gostorage.ErrNotExist,
+		},
+		{
+			name: "head object not found",
+			err:  awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), 404, "id"),
+			want: gostorage.ErrNotExist,
+		},
+		{
+			name: "access denied",
+			err:  awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), 403, "id"),
+			want: gostorage.ErrPermission,
+		},
+		{
+			name: "invalid range",
+			err:  awserr.NewRequestFailure(awserr.New("InvalidRange", "The requested range is not satisfiable", nil), 416, "id"),
+			want: gostorage.ErrInvalidRange,
+		},
+		{
+			name: "precondition failed status code",
+			err:  awserr.NewRequestFailure(awserr.New("Unknown", "", nil), 412, "id"),
+			want: gostorage.ErrPreconditionFailed,
+		},
+		{
+			name: "cancelled context",
+			err:  awserr.New(request.CanceledErrorCode, "request context canceled", context.Canceled),
+			want: context.Canceled,
+		},
+		{
+			name: "not an aws error",
+			err:  otherErr,
+			want: otherErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mapS3Error(tt.err)
+			if !errors.Is(got, tt.want) {
+				t.Errorf("mapS3Error() = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("mapS3Error() = %v, does not wrap %v", got, tt.err)
+			}
+		})
+	}
+}
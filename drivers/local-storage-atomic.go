@@ -0,0 +1,159 @@
+package drivers
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
+)
+
+// tempFilePrefix is the prefix of the temporary files the content is written to before
+// they are renamed into place. Temporary files are never listed.
+const tempFilePrefix = ".gostorage-tmp-"
+
+// isTempFile reports whether the file name is the name of a temporary file.
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, tempFilePrefix)
+}
+
+// createTemp creates a new temporary file within dir.
+func createTemp(dir string) (*os.File, error) {
+	return ioutil.TempFile(dir, tempFilePrefix+"*")
+}
+
+// linkTemp hard links the named file to a new temporary file within dir, the directory of
+// the file identified by key, and returns the path of the temporary file, which the caller
+// must remove if it is not committed.
+func (d LocalStorage) linkTemp(key, dir, name string) (string, error) {
+	random := make([]byte, 8)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	tmp := path.Join(dir, tempFilePrefix+hex.EncodeToString(random))
+	err = d.withParents(key, func() error {
+		return os.Link(name, tmp)
+	})
+	if err != nil {
+		return "", err
+	}
+	return tmp, nil
+}
+
+// writeTemp streams the content into a new temporary file within dir and flushes it
+// to stable storage. It returns the path of the temporary file, which the caller must
+// remove if it is not committed, and the ETag of the content computed while writing.
+func (d LocalStorage) writeTemp(ctx context.Context, dir string, r io.Reader) (string, string, error) {
+	file, err := createTemp(dir)
+	if err != nil {
+		return "", "", err
+	}
+	return d.fillTemp(ctx, file, r)
+}
+
+// fillTemp streams the content into the created temporary file like writeTemp.
+// The file is closed in any case.
+func (d LocalStorage) fillTemp(ctx context.Context, file *os.File, r io.Reader) (string, string, error) {
+	hash := md5.New()
+	_, err := io.Copy(io.MultiWriter(file, hash), utils.ContextReader(ctx, r))
+	if err == nil {
+		err = file.Chmod(d.filePermissions())
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", "", err
+	}
+	return file.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// commitTemp moves the temporary file to name, replacing an existing file atomically.
+// If exclusive is set, fs.ErrExist is returned instead of replacing an existing file.
+// If Durable is set, the directory of name is flushed to stable storage as well.
+func (d LocalStorage) commitTemp(tmp, name string, exclusive bool) error {
+	var err error
+	if exclusive {
+		// linking fails atomically if the file exists, unlike renaming
+		err = os.Link(tmp, name)
+		os.Remove(tmp)
+	} else {
+		err = os.Rename(tmp, name)
+	}
+	if err != nil {
+		return err
+	}
+	if d.Durable {
+		return syncDir(path.Dir(name))
+	}
+	return nil
+}
+
+// writeFileAtomic writes the content to the named file, so that readers either
+// observe the previous or the whole new content.
+func (d LocalStorage) writeFileAtomic(ctx context.Context, name string, r io.Reader) error {
+	tmp, _, err := d.writeTemp(ctx, path.Dir(name), r)
+	if err != nil {
+		return err
+	}
+	err = d.commitTemp(tmp, name, false)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// mkdirAll creates the directory including its missing parents. If Durable is set,
+// the parent of every created directory is flushed to stable storage, as the files
+// within a new directory are lost in a crash as long as the directory itself is.
+func (d LocalStorage) mkdirAll(dir string) error {
+	if !d.Durable {
+		return os.MkdirAll(dir, d.dirPermissions())
+	}
+	missing, err := missingDirs(dir)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, d.dirPermissions())
+	if err != nil {
+		return err
+	}
+	for _, name := range missing {
+		err = syncDir(path.Dir(name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// missingDirs returns the directory and its parents up to the first existing one,
+// deepest first, or nothing if the directory exists.
+func missingDirs(dir string) ([]string, error) {
+	missing := []string{}
+	for ; dir != path.Dir(dir); dir = path.Dir(dir) {
+		_, err := os.Stat(dir)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		missing = append(missing, dir)
+	}
+	return missing, nil
+}
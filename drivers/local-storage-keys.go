@@ -0,0 +1,81 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// validateKey returns gostorage.ErrInvalidKey unless the key is a relative, slash separated
+// path of a file below the root directory. Path elements like "." and "..", empty elements,
+// NUL bytes and the names reserved for the local storage itself are rejected.
+func validateKey(key string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("%w: empty key", gostorage.ErrInvalidKey)
+	case strings.IndexByte(key, 0) >= 0:
+		return fmt.Errorf("%w: key %q contains a NUL byte", gostorage.ErrInvalidKey, key)
+	case filepath.IsAbs(key) || filepath.VolumeName(key) != "" || strings.HasPrefix(key, "/"):
+		return fmt.Errorf("%w: key %q is an absolute path", gostorage.ErrInvalidKey, key)
+	case os.PathSeparator != '/' && strings.ContainsRune(key, os.PathSeparator):
+		return fmt.Errorf("%w: key %q contains a path separator other than slash", gostorage.ErrInvalidKey, key)
+	}
+	elems := strings.Split(key, "/")
+	for _, elem := range elems {
+		switch elem {
+		case "":
+			return fmt.Errorf("%w: key %q contains an empty path element", gostorage.ErrInvalidKey, key)
+		case ".", "..":
+			return fmt.Errorf("%w: key %q contains the path element %q", gostorage.ErrInvalidKey, key, elem)
+		}
+	}
+	if elems[0] == internalDir || isTempFile(elems[len(elems)-1]) {
+		return fmt.Errorf("%w: key %q uses a name reserved for the local storage", gostorage.ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// checkSymlinks returns gostorage.ErrInvalidKey if the path, or the deepest of its parent
+// directories that exists, resolves to a location outside of the root directory through
+// symbolic links. Links that are changed concurrently are not detected.
+func (d LocalStorage) checkSymlinks(name string) error {
+	root, err := resolvePath(d.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// without a root directory there are no links to follow
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	existing := filepath.Clean(name)
+	for {
+		resolved, err := resolvePath(existing)
+		missing := errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)
+		if missing && filepath.Dir(existing) != existing {
+			existing = filepath.Dir(existing)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+			return fmt.Errorf("%w: %s resolves to %s outside of the root directory", gostorage.ErrInvalidKey, name, resolved)
+		}
+		return nil
+	}
+}
+
+// resolvePath returns the absolute path of name with all symbolic links resolved.
+func resolvePath(name string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(resolved)
+}
@@ -0,0 +1,34 @@
+package drivers
+
+import (
+	"os"
+	"path"
+)
+
+// lockFile is the file below the internal directory that is locked to
+// serialize the changes to the files of the local storage.
+const lockFile = "lock"
+
+// lock acquires the exclusive lock of the local storage. The lock is shared with
+// other processes using the same root directory where the platform supports it.
+// The returned function releases the lock.
+func (d LocalStorage) lock() (func(), error) {
+	dir := path.Join(d.Path, internalDir)
+	err := os.MkdirAll(dir, d.dirPermissions())
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path.Join(dir, lockFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	err = lockExclusive(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockExclusive(file)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,25 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package drivers
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockExclusive blocks until the exclusive flock of the file is acquired.
+// Every call must use a separately opened file, as flock locks are bound to it.
+func lockExclusive(file *os.File) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// unlockExclusive releases the flock of the file.
+func unlockExclusive(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,25 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package drivers
+
+import (
+	"os"
+	"sync"
+)
+
+// lockMutex serializes the changes on platforms without flock.
+// It only protects against concurrent changes within the same process.
+var lockMutex sync.Mutex
+
+// lockExclusive blocks until the process wide lock is acquired.
+func lockExclusive(file *os.File) error {
+	lockMutex.Lock()
+	return nil
+}
+
+// unlockExclusive releases the process wide lock.
+func unlockExclusive(file *os.File) error {
+	lockMutex.Unlock()
+	return nil
+}
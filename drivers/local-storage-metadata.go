@@ -0,0 +1,168 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// internalDir is the directory below the root directory of the local storage
+// that holds the data managed by the driver itself. It is never listed.
+const internalDir = ".gostorage"
+
+// fileMetadata defines the content of the sidecar file that stores the
+// attributes of a file written with gostorage.WriteOptions.
+type fileMetadata struct {
+	ContentType        string            `json:"contentType,omitempty"`
+	CacheControl       string            `json:"cacheControl,omitempty"`
+	ContentDisposition string            `json:"contentDisposition,omitempty"`
+	ContentEncoding    string            `json:"contentEncoding,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	// VersionID is the version of the file if Versioning is enabled.
+	VersionID string `json:"versionId,omitempty"`
+	// ETag is the ETag of the content computed while the file has been written.
+	// It is only valid as long as the size and the modification time of the file,
+	// in nanoseconds since the epoch, match, as the file may be modified externally.
+	ETag    string `json:"etag,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	ModTime int64  `json:"modTime,omitempty"`
+}
+
+// newFileMetadata returns the attributes to store for the given options.
+// If the options do not define any attribute, nil is returned.
+func newFileMetadata(opts gostorage.WriteOptions) *fileMetadata {
+	md := &fileMetadata{
+		ContentType:        opts.ContentType,
+		CacheControl:       opts.CacheControl,
+		ContentDisposition: opts.ContentDisposition,
+		ContentEncoding:    opts.ContentEncoding,
+	}
+	if len(opts.Metadata) > 0 {
+		md.Metadata = make(map[string]string, len(opts.Metadata))
+		for k, v := range opts.Metadata {
+			md.Metadata[strings.ToLower(k)] = v
+		}
+	}
+	if md.ContentType == "" && md.CacheControl == "" && md.ContentDisposition == "" &&
+		md.ContentEncoding == "" && md.Metadata == nil {
+		return nil
+	}
+	return md
+}
+
+// apply copies the attributes to the object info.
+func (md *fileMetadata) apply(info *gostorage.ObjectInfo) {
+	if md.ContentType != "" {
+		info.ContentType = md.ContentType
+	}
+	info.CacheControl = md.CacheControl
+	info.ContentDisposition = md.ContentDisposition
+	info.ContentEncoding = md.ContentEncoding
+	info.Metadata = md.Metadata
+	info.VersionID = md.VersionID
+}
+
+// setETag stores the ETag of the content of the file described by fInfo.
+func (md *fileMetadata) setETag(etag string, fInfo fs.FileInfo) {
+	md.ETag = etag
+	md.Size = fInfo.Size()
+	md.ModTime = fInfo.ModTime().UnixNano()
+}
+
+// etag returns the stored ETag of the file described by fInfo, or an empty string
+// if no ETag has been stored or the file has been modified since.
+func (md *fileMetadata) etag(fInfo fs.FileInfo) string {
+	if md == nil || md.Size != fInfo.Size() || md.ModTime != fInfo.ModTime().UnixNano() {
+		return ""
+	}
+	return md.ETag
+}
+
+// writeOptions returns the options to write a file with the same attributes.
+func (md *fileMetadata) writeOptions() gostorage.WriteOptions {
+	if md == nil {
+		return gostorage.WriteOptions{}
+	}
+	return gostorage.WriteOptions{
+		ContentType:        md.ContentType,
+		CacheControl:       md.CacheControl,
+		ContentDisposition: md.ContentDisposition,
+		ContentEncoding:    md.ContentEncoding,
+		Metadata:           md.Metadata,
+	}
+}
+
+// metadataPath returns the path of the sidecar file of the file identified by key.
+// The sidecar files are named by the hash of the key, as the key itself could name
+// a directory of another key's sidecar, e.g. "a" and "a.json/b".
+func (d LocalStorage) metadataPath(key string) string {
+	return path.Join(d.Path, internalDir, "metadata", keyHash(key)+".json")
+}
+
+// keyHash returns the hex encoded SHA-256 checksum of the key.
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// readMetadata returns the attributes of the file identified by key.
+// If the file has no attributes, nil is returned.
+func (d LocalStorage) readMetadata(key string) (*fileMetadata, error) {
+	mdPath := d.metadataPath(key)
+	bts, err := ioutil.ReadFile(mdPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, mdPath)
+	}
+	md := &fileMetadata{}
+	err = json.Unmarshal(bts, md)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode metadata %s: %w", mdPath, err)
+	}
+	return md, nil
+}
+
+// writeMetadata stores the attributes of the file identified by key.
+// If md is nil, the previously stored attributes are removed.
+func (d LocalStorage) writeMetadata(key string, md *fileMetadata) error {
+	if md == nil {
+		return d.deleteMetadata(key)
+	}
+	mdPath := d.metadataPath(key)
+	bts, err := json.Marshal(md)
+	if err != nil {
+		return fmt.Errorf("unable to encode metadata %s: %w", mdPath, err)
+	}
+	err = d.mkdirAll(path.Dir(mdPath))
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, mdPath)
+	}
+	err = d.writeFileAtomic(context.Background(), mdPath, bytes.NewReader(bts))
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, mdPath)
+	}
+	return nil
+}
+
+// deleteMetadata removes the attributes of the file identified by key.
+func (d LocalStorage) deleteMetadata(key string) error {
+	mdPath := d.metadataPath(key)
+	err := os.Remove(mdPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", err, mdPath)
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+const (
+	// uploadsDir is the directory below the internal directory that holds the multipart
+	// uploads. Every upload has its own directory containing the upload file and the parts.
+	uploadsDir = "uploads"
+	// uploadFile is the file within the directory of an upload that describes the upload.
+	uploadFile = "upload.json"
+	// partFilePrefix is the prefix of the part files, followed by the part number.
+	partFilePrefix = "part-"
+	// partMetadataSuffix is the suffix of the sidecar file of a part file, which stores
+	// the ETag of the part like the sidecar file of a file.
+	partMetadataSuffix = ".json"
+	// maxPartNumber is the highest part number, the same as for S3.
+	maxPartNumber = 10000
+)
+
+// localUpload defines the content of the upload file.
+type localUpload struct {
+	Key      string        `json:"key"`
+	Metadata *fileMetadata `json:"metadata,omitempty"`
+}
+
+// uploadDir returns the directory of the upload. Upload IDs that have not been
+// created by the local storage are reported as uploads that do not exist.
+func (d LocalStorage) uploadDir(upload *gostorage.Upload) (string, error) {
+	if _, err := hex.DecodeString(upload.UploadID); err != nil || upload.UploadID == "" {
+		return "", fmt.Errorf("%w: invalid upload id %q", gostorage.ErrNotExist, upload.UploadID)
+	}
+	return path.Join(d.Path, internalDir, uploadsDir, upload.UploadID), nil
+}
+
+// readUpload returns the directory and the description of the upload.
+func (d LocalStorage) readUpload(upload *gostorage.Upload) (string, *localUpload, error) {
+	dir, err := d.uploadDir(upload)
+	if err != nil {
+		return "", nil, err
+	}
+	bts, err := ioutil.ReadFile(path.Join(dir, uploadFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, fmt.Errorf("%w: upload %s", gostorage.ErrNotExist, upload.UploadID)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	lu := &localUpload{}
+	err = json.Unmarshal(bts, lu)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to decode upload %s: %w", upload.UploadID, err)
+	}
+	if lu.Key != upload.Key {
+		return "", nil, fmt.Errorf("%w: upload %s of key %s", gostorage.ErrNotExist, upload.UploadID, upload.Key)
+	}
+	return dir, lu, nil
+}
+
+// partPath returns the path of the part file within the directory of an upload.
+func partPath(dir string, number int) string {
+	return path.Join(dir, fmt.Sprintf("%s%05d", partFilePrefix, number))
+}
+
+// partETag returns the ETag and the file info of the named part file. The ETag stored while
+// the part has been uploaded is used, unless the part file has been modified since.
+func partETag(ctx context.Context, name string) (string, fs.FileInfo, error) {
+	fInfo, err := os.Stat(name)
+	if err != nil {
+		return "", nil, err
+	}
+	md := &fileMetadata{}
+	if bts, err := ioutil.ReadFile(name + partMetadataSuffix); err == nil && json.Unmarshal(bts, md) == nil {
+		if etag := md.etag(fInfo); etag != "" {
+			return etag, fInfo, nil
+		}
+	}
+	etag, err := fileETag(ctx, name)
+	if err != nil {
+		return "", nil, err
+	}
+	return etag, fInfo, nil
+}
+
+// writePartETag stores the ETag of the content of the named part file described by fInfo.
+func (d LocalStorage) writePartETag(ctx context.Context, name, etag string, fInfo fs.FileInfo) error {
+	md := &fileMetadata{}
+	md.setETag(etag, fInfo)
+	bts, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	return d.writeFileAtomic(ctx, name+partMetadataSuffix, bytes.NewReader(bts))
+}
+
+// InitiateUpload starts an upload of the file identified by key. The parts are stored
+// in the internal directory until the upload is completed or aborted.
+func (d LocalStorage) InitiateUpload(key string, opts gostorage.WriteOptions) (*gostorage.Upload, error) {
+	return d.InitiateUploadContext(context.Background(), key, opts)
+}
+
+// InitiateUploadContext starts an upload of the file identified by key. The parts are stored
+// in the internal directory until the upload is completed or aborted.
+func (d LocalStorage) InitiateUploadContext(ctx context.Context, key string, opts gostorage.WriteOptions) (*gostorage.Upload, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, localStorageError("initiate upload", key, err)
+	}
+	if opts.IfNotExists || opts.IfMatch != "" {
+		return nil, localStorageError("initiate upload", key, fmt.Errorf("%w: conditional multipart uploads", gostorage.ErrNotSupported))
+	}
+	if _, err := d.fullPath(key); err != nil {
+		return nil, localStorageError("initiate upload", key, err)
+	}
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, localStorageError("initiate upload", key, err)
+	}
+	upload := &gostorage.Upload{
+		Key:      key,
+		UploadID: hex.EncodeToString(id),
+	}
+	dir, _ := d.uploadDir(upload)
+	err = d.mkdirAll(dir)
+	if err != nil {
+		return nil, localStorageError("initiate upload", key, err)
+	}
+	bts, err := json.Marshal(&localUpload{
+		Key:      key,
+		Metadata: newFileMetadata(opts),
+	})
+	if err != nil {
+		return nil, localStorageError("initiate upload", key, err)
+	}
+	err = ioutil.WriteFile(path.Join(dir, uploadFile), bts, d.filePermissions())
+	if err != nil {
+		return nil, localStorageError("initiate upload", key, err)
+	}
+	return upload, nil
+}
+
+// UploadPart stores the part in a part file of the upload.
+func (d LocalStorage) UploadPart(upload *gostorage.Upload, number int, value io.Reader) (*gostorage.Part, error) {
+	return d.UploadPartContext(context.Background(), upload, number, value)
+}
+
+// UploadPartContext stores the part in a part file of the upload.
+// The part file is replaced atomically, so that a part is either listed
+// with its whole content or not at all. The ETag of the part is stored
+// next to it, so that the part is not read again to list it.
+func (d LocalStorage) UploadPartContext(ctx context.Context, upload *gostorage.Upload, number int, value io.Reader) (*gostorage.Part, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, localStorageError("upload part", upload.Key, err)
+	}
+	if number < 1 || number > maxPartNumber {
+		return nil, localStorageError("upload part", upload.Key, fmt.Errorf("invalid part number %d, must be between 1 and %d", number, maxPartNumber))
+	}
+	dir, _, err := d.readUpload(upload)
+	if err != nil {
+		return nil, localStorageError("upload part", upload.Key, err)
+	}
+
+	tmp, etag, err := d.writeTemp(ctx, dir, value)
+	if err != nil {
+		return nil, localStorageError("upload part", upload.Key, err)
+	}
+	fInfo, err := os.Stat(tmp)
+	if err == nil {
+		err = d.commitTemp(tmp, partPath(dir, number), false)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, localStorageError("upload part", upload.Key, err)
+	}
+	err = d.writePartETag(ctx, partPath(dir, number), etag, fInfo)
+	if err != nil {
+		return nil, localStorageError("upload part", upload.Key, err)
+	}
+	return &gostorage.Part{
+		Number: number,
+		ETag:   etag,
+		Size:   fInfo.Size(),
+	}, nil
+}
+
+// ListParts returns the parts of the upload stored so far.
+func (d LocalStorage) ListParts(upload *gostorage.Upload) ([]gostorage.Part, error) {
+	return d.ListPartsContext(context.Background(), upload)
+}
+
+// ListPartsContext returns the parts of the upload stored so far.
+// The ETags are the MD5 checksums of the parts. They are stored while uploading the parts,
+// so the parts are only read if they have been modified since.
+func (d LocalStorage) ListPartsContext(ctx context.Context, upload *gostorage.Upload) ([]gostorage.Part, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, localStorageError("list parts", upload.Key, err)
+	}
+	dir, _, err := d.readUpload(upload)
+	if err != nil {
+		return nil, localStorageError("list parts", upload.Key, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, localStorageError("list parts", upload.Key, err)
+	}
+	parts := []gostorage.Part{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), partFilePrefix) {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), partFilePrefix))
+		if err != nil {
+			continue
+		}
+		etag, info, err := partETag(ctx, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, localStorageError("list parts", upload.Key, err)
+		}
+		parts = append(parts, gostorage.Part{
+			Number: number,
+			ETag:   etag,
+			Size:   info.Size(),
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Number < parts[j].Number
+	})
+	return parts, nil
+}
+
+// CompleteUpload writes the concatenated parts to the file identified by the key
+// of the upload and removes the part files.
+func (d LocalStorage) CompleteUpload(upload *gostorage.Upload, parts []gostorage.Part) error {
+	return d.CompleteUploadContext(context.Background(), upload, parts)
+}
+
+// CompleteUploadContext writes the concatenated parts to the file identified by the key
+// of the upload and removes the part files. The parts must be in ascending order and
+// their ETags must match the stored parts.
+func (d LocalStorage) CompleteUploadContext(ctx context.Context, upload *gostorage.Upload, parts []gostorage.Part) error {
+	if err := ctx.Err(); err != nil {
+		return localStorageError("complete upload", upload.Key, err)
+	}
+	dir, lu, err := d.readUpload(upload)
+	if err != nil {
+		return localStorageError("complete upload", upload.Key, err)
+	}
+	if len(parts) == 0 {
+		return localStorageError("complete upload", upload.Key, errors.New("no parts"))
+	}
+
+	readers := make([]io.Reader, 0, len(parts))
+	for i, part := range parts {
+		if i > 0 && part.Number <= parts[i-1].Number {
+			return localStorageError("complete upload", upload.Key, fmt.Errorf("part %d is not in ascending order", part.Number))
+		}
+		etag, _, err := partETag(ctx, partPath(dir, part.Number))
+		if errors.Is(err, fs.ErrNotExist) {
+			return localStorageError("complete upload", upload.Key, fmt.Errorf("%w: part %d", gostorage.ErrNotExist, part.Number))
+		}
+		if err != nil {
+			return localStorageError("complete upload", upload.Key, err)
+		}
+		if etag != unquoteETag(part.ETag) {
+			return localStorageError("complete upload", upload.Key, fmt.Errorf("etag %q of part %d does not match %q", part.ETag, part.Number, etag))
+		}
+		file, err := os.Open(partPath(dir, part.Number))
+		if err != nil {
+			return localStorageError("complete upload", upload.Key, err)
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+	err = d.WriteWithOptionsContext(ctx, upload.Key, io.MultiReader(readers...), lu.Metadata.writeOptions())
+	if err != nil {
+		return err
+	}
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return localStorageError("complete upload", upload.Key, err)
+	}
+	return nil
+}
+
+// AbortUpload removes the upload including all part files.
+func (d LocalStorage) AbortUpload(upload *gostorage.Upload) error {
+	return d.AbortUploadContext(context.Background(), upload)
+}
+
+// AbortUploadContext removes the upload including all part files.
+func (d LocalStorage) AbortUploadContext(ctx context.Context, upload *gostorage.Upload) error {
+	if err := ctx.Err(); err != nil {
+		return localStorageError("abort upload", upload.Key, err)
+	}
+	dir, _, err := d.readUpload(upload)
+	if err != nil {
+		return localStorageError("abort upload", upload.Key, err)
+	}
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return localStorageError("abort upload", upload.Key, err)
+	}
+	return nil
+}
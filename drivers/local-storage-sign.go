@@ -0,0 +1,137 @@
+package drivers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+const (
+	// signedURLExpires is the query parameter of a signed URL holding the unix time it expires at.
+	signedURLExpires = "expires"
+	// signedURLSignature is the query parameter of a signed URL holding its signature.
+	signedURLSignature = "signature"
+)
+
+// signingCapability returns gostorage.CapabilityPresignedURL if the local storage is able to sign URLs.
+func (d LocalStorage) signingCapability() gostorage.Capability {
+	if len(d.SigningKey) == 0 || d.BaseURL == "" {
+		return 0
+	}
+	return gostorage.CapabilityPresignedURL
+}
+
+// SignURL returns an URL below BaseURL that allows GET or PUT requests on the file
+// until the expiry has passed. The URL is signed by HMAC-SHA256 with SigningKey
+// and verified by the handler returned by SignedURLHandler.
+func (d LocalStorage) SignURL(method, key string, expiry time.Duration) (string, error) {
+	if d.signingCapability() == 0 {
+		return "", localStorageError("sign", key, fmt.Errorf("%w: SigningKey and BaseURL are required", gostorage.ErrNotSupported))
+	}
+	if method != http.MethodGet && method != http.MethodPut {
+		return "", localStorageError("sign", key, fmt.Errorf("%w: method %s", gostorage.ErrNotSupported, method))
+	}
+	if err := validateKey(key); err != nil {
+		return "", localStorageError("sign", key, err)
+	}
+	base, err := url.Parse(d.BaseURL)
+	if err != nil {
+		return "", localStorageError("sign", key, err)
+	}
+	expires := time.Now().Add(expiry).Unix()
+
+	signedURL := *base
+	signedURL.Path = strings.TrimSuffix(base.Path, "/") + "/" + key
+	signedURL.RawPath = ""
+	query := url.Values{}
+	query.Set(signedURLExpires, strconv.FormatInt(expires, 10))
+	query.Set(signedURLSignature, hex.EncodeToString(d.sign(method, key, expires)))
+	signedURL.RawQuery = query.Encode()
+	return signedURL.String(), nil
+}
+
+// sign returns the HMAC-SHA256 of the request on the file identified by key.
+func (d LocalStorage) sign(method, key string, expires int64) []byte {
+	mac := hmac.New(sha256.New, d.SigningKey)
+	fmt.Fprintf(mac, "%s\n%s\n%d", method, key, expires)
+	return mac.Sum(nil)
+}
+
+// verify reports whether the query contains a valid signature of the request
+// on the file identified by key that has not expired yet.
+func (d LocalStorage) verify(method, key string, query url.Values) bool {
+	if d.signingCapability() == 0 {
+		return false
+	}
+	expires, err := strconv.ParseInt(query.Get(signedURLExpires), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	signature, err := hex.DecodeString(query.Get(signedURLSignature))
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(signature, d.sign(method, key, expires))
+}
+
+// SignedURLHandler returns the handler serving the URLs returned by SignURL.
+// It must be served at BaseURL. GET requests, and HEAD requests signed for GET,
+// are served like gostorage.Handler. PUT requests write the body to the file,
+// using the Content-Type header as content type if present.
+// Requests without a valid signature are rejected with 403 Forbidden.
+func (d LocalStorage) SignedURLHandler() http.Handler {
+	basePath := ""
+	if base, err := url.Parse(d.BaseURL); err == nil {
+		basePath = strings.TrimSuffix(base.Path, "/")
+	}
+	files := gostorage.NewHandler(d)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, basePath+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, basePath+"/")
+		method := r.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+		if !d.verify(method, key, r.URL.Query()) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			fileReq := r.Clone(r.Context())
+			fileReq.URL.Path = "/" + key
+			fileReq.URL.RawPath = ""
+			files.ServeHTTP(w, fileReq)
+		case http.MethodPut:
+			err := d.WriteWithOptionsContext(r.Context(), key, r.Body, gostorage.WriteOptions{
+				ContentType: r.Header.Get("Content-Type"),
+			})
+			if err != nil {
+				code := http.StatusInternalServerError
+				if errors.Is(err, gostorage.ErrInvalidKey) {
+					code = http.StatusBadRequest
+				}
+				http.Error(w, http.StatusText(code), code)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
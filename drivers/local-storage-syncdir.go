@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package drivers
+
+import "os"
+
+// syncDir flushes the directory to stable storage, so that renamed and
+// created files within it survive a crash of the system.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = file.Sync()
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package drivers
+
+// syncDir does nothing, since windows does not support flushing directories.
+func syncDir(dir string) error {
+	return nil
+}
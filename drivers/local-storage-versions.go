@@ -0,0 +1,463 @@
+package drivers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
+)
+
+const (
+	// versionsDir is the directory below the internal directory that holds the previous
+	// versions of the files. The versions of a file are stored in a directory named by the
+	// hash of its key, each version in a file named like its version ID. The key itself is
+	// not used, as the version IDs would collide with the keys below it, e.g. "a/null".
+	versionsDir = "versions"
+	// versionMetadataSuffix is the suffix of the sidecar file of a previous version.
+	versionMetadataSuffix = ".json"
+	// deleteMarkerSuffix is the suffix of the empty files marking a file as deleted.
+	deleteMarkerSuffix = ".deleted"
+)
+
+// versionIDPattern matches the version IDs created by newVersionID.
+var versionIDPattern = regexp.MustCompile(`^[0-9]{19}-[0-9a-f]{8}$`)
+
+// localVersion is a previous version of a file or a delete marker.
+type localVersion struct {
+	id           string
+	deleteMarker bool
+	info         fs.FileInfo
+}
+
+// versionID returns the version ID of the file the attributes belong to.
+// Files written before versioning has been enabled have the null version ID.
+func (md *fileMetadata) versionID() string {
+	if md == nil || md.VersionID == "" {
+		return gostorage.NullVersionID
+	}
+	return md.VersionID
+}
+
+// newVersionID returns a new unique version ID. Version IDs created later sort after
+// the ones created earlier.
+func newVersionID() (string, error) {
+	random := make([]byte, 4)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%019d-%s", time.Now().UnixNano(), hex.EncodeToString(random)), nil
+}
+
+// newerVersion reports whether the version ID a has been created after b.
+// The null version is older than any other version.
+func newerVersion(a, b string) bool {
+	if a == gostorage.NullVersionID {
+		return false
+	}
+	if b == gostorage.NullVersionID {
+		return true
+	}
+	return a > b
+}
+
+// validVersionID reports whether the version ID is the null version ID or has been
+// created by newVersionID. Any other ID, like the name of a sidecar file, is rejected.
+func validVersionID(id string) bool {
+	return id == gostorage.NullVersionID || versionIDPattern.MatchString(id)
+}
+
+// versionPath returns the path of the previous version of the file identified by key.
+func (d LocalStorage) versionPath(key, versionID string) string {
+	return path.Join(d.Path, internalDir, versionsDir, keyHash(key), versionID)
+}
+
+// versioningCapability returns gostorage.CapabilityVersioning if the local storage keeps
+// the previous versions of the files.
+func (d LocalStorage) versioningCapability() gostorage.Capability {
+	if !d.Versioning {
+		return 0
+	}
+	return gostorage.CapabilityVersioning
+}
+
+// archiveVersion links the current version of the file identified by key including its
+// attributes into the versions directory. The file itself stays in place, so that readers
+// never miss it until it is replaced. The returned function removes the links again, which
+// is used if replacing the file fails. If the file does not exist, nothing is archived.
+// The caller must hold the lock of the local storage.
+func (d LocalStorage) archiveVersion(key string) (func(), error) {
+	filePath, err := d.fullPath(key)
+	if err != nil {
+		return nil, err
+	}
+	fInfo, err := os.Stat(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return func() {}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fInfo.IsDir() {
+		return nil, fmt.Errorf("%w: %s is a directory", gostorage.ErrNotExist, filePath)
+	}
+	md, err := d.readMetadata(key)
+	if err != nil {
+		return nil, err
+	}
+
+	versionPath := d.versionPath(key, md.versionID())
+	err = d.mkdirAll(path.Dir(versionPath))
+	if err != nil {
+		return nil, err
+	}
+	// a previously archived null version is replaced, like it is by S3
+	for _, name := range []string{versionPath, versionPath + versionMetadataSuffix} {
+		err = os.Remove(name)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	err = os.Link(filePath, versionPath)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Link(d.metadataPath(key), versionPath+versionMetadataSuffix)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		os.Remove(versionPath)
+		return nil, err
+	}
+	return func() {
+		os.Remove(versionPath)
+		os.Remove(versionPath + versionMetadataSuffix)
+	}, nil
+}
+
+// deleteVersioned archives the current version of the file identified by key,
+// marks the file as deleted and removes it.
+func (d LocalStorage) deleteVersioned(key string) error {
+	unlock, err := d.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	filePath, err := d.fullPath(key)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return err
+	}
+	id, err := newVersionID()
+	if err != nil {
+		return err
+	}
+	restore, err := d.archiveVersion(key)
+	if err != nil {
+		return err
+	}
+	marker, err := os.OpenFile(d.versionPath(key, id)+deleteMarkerSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, d.filePermissions())
+	if err != nil {
+		restore()
+		return err
+	}
+	err = marker.Close()
+	if err != nil {
+		restore()
+		os.Remove(marker.Name())
+		return err
+	}
+	err = os.Remove(filePath)
+	if err != nil {
+		return err
+	}
+	return d.deleteMetadata(key)
+}
+
+// archivedVersions returns the previous versions and delete markers of the file
+// identified by key, newest first.
+func (d LocalStorage) archivedVersions(key string) ([]localVersion, error) {
+	entries, err := os.ReadDir(path.Dir(d.versionPath(key, gostorage.NullVersionID)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions := []localVersion{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), versionMetadataSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, localVersion{
+			id:           strings.TrimSuffix(entry.Name(), deleteMarkerSuffix),
+			deleteMarker: strings.HasSuffix(entry.Name(), deleteMarkerSuffix),
+			info:         info,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return newerVersion(versions[i].id, versions[j].id)
+	})
+	return versions, nil
+}
+
+// ListVersions returns the versions of the file identified by key, newest first.
+// The previous versions are only kept if Versioning is enabled.
+func (d LocalStorage) ListVersions(key string) ([]gostorage.ObjectVersion, error) {
+	return d.ListVersionsContext(context.Background(), key)
+}
+
+// ListVersionsContext returns the versions of the file identified by key, newest first.
+// The previous versions are only kept if Versioning is enabled.
+// The ETags are the MD5 checksums of the versions, so all previous versions are read.
+func (d LocalStorage) ListVersionsContext(ctx context.Context, key string) ([]gostorage.ObjectVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, localStorageError("list versions", key, err)
+	}
+	filePath, err := d.fullPath(key)
+	if err != nil {
+		return nil, localStorageError("list versions", key, err)
+	}
+	unlock, err := d.lock()
+	if err != nil {
+		return nil, localStorageError("list versions", key, err)
+	}
+	defer unlock()
+
+	versions := []gostorage.ObjectVersion{}
+	fInfo, err := os.Stat(filePath)
+	switch {
+	case err == nil && !fInfo.IsDir():
+		md, err := d.readMetadata(key)
+		if err != nil {
+			return nil, localStorageError("list versions", key, err)
+		}
+		etag := md.etag(fInfo)
+		if etag == "" {
+			etag, err = fileETag(ctx, filePath)
+			if err != nil {
+				return nil, localStorageError("list versions", key, err)
+			}
+		}
+		versions = append(versions, gostorage.ObjectVersion{
+			Key:          key,
+			VersionID:    md.versionID(),
+			Size:         fInfo.Size(),
+			LastModified: fInfo.ModTime(),
+			ETag:         etag,
+		})
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return nil, localStorageError("list versions", key, err)
+	}
+
+	archived, err := d.archivedVersions(key)
+	if err != nil {
+		return nil, localStorageError("list versions", key, err)
+	}
+	for _, v := range archived {
+		version := gostorage.ObjectVersion{
+			Key:          key,
+			VersionID:    v.id,
+			LastModified: v.info.ModTime(),
+			DeleteMarker: v.deleteMarker,
+		}
+		if !v.deleteMarker {
+			version.Size = v.info.Size()
+			version.ETag, err = fileETag(ctx, d.versionPath(key, v.id))
+			if err != nil {
+				return nil, localStorageError("list versions", key, err)
+			}
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return nil, localStorageError("list versions", key, fmt.Errorf("%w: no versions of %s", gostorage.ErrNotExist, key))
+	}
+	versions[0].IsLatest = true
+	return versions, nil
+}
+
+// fileETag returns the ETag of the named file.
+func fileETag(ctx context.Context, name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return contentETag(ctx, file)
+}
+
+// ReadVersion returns the opened version of the file identified by key.
+// The caller must close the returned reader.
+func (d LocalStorage) ReadVersion(key, versionID string) (io.ReadCloser, error) {
+	return d.ReadVersionContext(context.Background(), key, versionID)
+}
+
+// ReadVersionContext returns the opened version of the file identified by key.
+// Reading from the returned reader fails once the context is done.
+// Delete markers do not have any content and are reported as not existing.
+// The caller must close the returned reader.
+func (d LocalStorage) ReadVersionContext(ctx context.Context, key, versionID string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, localStorageError("read", key, err)
+	}
+	if _, err := d.fullPath(key); err != nil {
+		return nil, localStorageError("read", key, err)
+	}
+	if !validVersionID(versionID) {
+		return nil, localStorageError("read", key, fmt.Errorf("%w: invalid version id %q", gostorage.ErrNotExist, versionID))
+	}
+	md, err := d.readMetadata(key)
+	if err != nil {
+		return nil, localStorageError("read", key, err)
+	}
+	if md.versionID() == versionID {
+		rc, err := d.ReadStreamContext(ctx, key)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return rc, err
+		}
+		// the null version may have been archived already
+	}
+
+	versionPath := d.versionPath(key, versionID)
+	if _, err := os.Stat(versionPath + deleteMarkerSuffix); err == nil {
+		return nil, localStorageError("read", key, fmt.Errorf("%w: version %s is a delete marker", gostorage.ErrNotExist, versionID))
+	}
+	file, err := os.Open(versionPath)
+	if err != nil {
+		return nil, localStorageError("read", key, err)
+	}
+	return &fileReader{
+		Reader: utils.ContextReader(ctx, file),
+		file:   file,
+	}, nil
+}
+
+// DeleteVersion deletes the version of the file identified by key permanently.
+// If the current version or the latest delete marker is deleted, the previous
+// version is restored.
+func (d LocalStorage) DeleteVersion(key, versionID string) error {
+	return d.DeleteVersionContext(context.Background(), key, versionID)
+}
+
+// DeleteVersionContext deletes the version of the file identified by key permanently.
+// If the current version or the latest delete marker is deleted, the previous
+// version is restored.
+func (d LocalStorage) DeleteVersionContext(ctx context.Context, key, versionID string) error {
+	if err := ctx.Err(); err != nil {
+		return localStorageError("delete", key, err)
+	}
+	if _, err := d.fullPath(key); err != nil {
+		return localStorageError("delete", key, err)
+	}
+	if !validVersionID(versionID) {
+		return localStorageError("delete", key, fmt.Errorf("%w: invalid version id %q", gostorage.ErrNotExist, versionID))
+	}
+	unlock, err := d.lock()
+	if err != nil {
+		return localStorageError("delete", key, err)
+	}
+	defer unlock()
+
+	err = d.removeVersion(key, versionID)
+	if err != nil {
+		return localStorageError("delete", key, err)
+	}
+	err = d.restoreLatestVersion(key)
+	if err != nil {
+		return localStorageError("delete", key, err)
+	}
+	// removing the directory of the versions fails unless the last version has been removed
+	_ = os.Remove(path.Dir(d.versionPath(key, versionID)))
+	return nil
+}
+
+// removeVersion removes the version of the file identified by key, which is either
+// the current version, a previous version or a delete marker.
+func (d LocalStorage) removeVersion(key, versionID string) error {
+	md, err := d.readMetadata(key)
+	if err != nil {
+		return err
+	}
+	if md.versionID() == versionID {
+		filePath, err := d.fullPath(key)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(filePath)
+		if err == nil {
+			return d.deleteMetadata(key)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	versionPath := d.versionPath(key, versionID)
+	err = os.Remove(versionPath + deleteMarkerSuffix)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	err = os.Remove(versionPath)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(versionPath + versionMetadataSuffix)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// restoreLatestVersion makes the latest previous version of the file identified by key
+// the current version, unless the file exists or the latest version is a delete marker.
+func (d LocalStorage) restoreLatestVersion(key string) error {
+	filePath, err := d.fullPath(key)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filePath); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	archived, err := d.archivedVersions(key)
+	if err != nil || len(archived) == 0 || archived[0].deleteMarker {
+		return err
+	}
+
+	versionPath := d.versionPath(key, archived[0].id)
+	err = d.mkdirAll(path.Dir(filePath))
+	if err != nil {
+		return err
+	}
+	err = os.Rename(versionPath, filePath)
+	if err != nil {
+		return err
+	}
+	err = d.mkdirAll(path.Dir(d.metadataPath(key)))
+	if err != nil {
+		return err
+	}
+	err = os.Rename(versionPath+versionMetadataSuffix, d.metadataPath(key))
+	if errors.Is(err, fs.ErrNotExist) && archived[0].id != gostorage.NullVersionID {
+		// the sidecar file of the version is missing
+		return d.writeMetadata(key, &fileMetadata{VersionID: archived[0].id})
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// errStopWalk is returned by a walkFunc to stop the walk without an error.
+var errStopWalk = errors.New("stop walk")
+
+// walkFunc is called by walk for every file.
+// The key is the slash separated path of the file relative to the root directory.
+type walkFunc func(key string, info fs.FileInfo) error
+
+// skipFunc is called by walk before a directory is read. The dirKey is the key
+// of the directory including a trailing slash. If it returns true, the directory is skipped.
+type skipFunc func(dirKey string) bool
+
+// walk calls fn for every file below the root directory whose key starts with prefix
+// and is lexically greater than startAfter. The files are visited in lexical order of
+// their keys, and directories that can not contain a matching key are not read at all.
+// If skip is not nil, it is consulted before a directory is read.
+func (d LocalStorage) walk(ctx context.Context, prefix, startAfter string, skip skipFunc, fn walkFunc) error {
+	err := d.walkDir(ctx, "", prefix, startAfter, skip, fn)
+	if errors.Is(err, errStopWalk) {
+		return nil
+	}
+	return err
+}
+
+// walkDir walks the directory identified by dir, which is either empty for the root
+// directory or the key of the directory including a trailing slash.
+func (d LocalStorage) walkDir(ctx context.Context, dir, prefix, startAfter string, skip skipFunc, fn walkFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dirPath := path.Join(d.Path, dir)
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		if dir != "" && errors.Is(err, fs.ErrNotExist) {
+			// the directory has been removed while walking
+			return nil
+		}
+		return fmt.Errorf("%w: %s", err, dirPath)
+	}
+	// the keys of the files within a directory start with the directory name followed by
+	// a slash, therefore directories must be sorted as if their name ends with a slash
+	sort.Slice(entries, func(i, j int) bool {
+		return entryKey(entries[i]) < entryKey(entries[j])
+	})
+
+	for _, entry := range entries {
+		key := dir + entryKey(entry)
+		if entry.IsDir() {
+			if key == internalDir+"/" || !mayContain(key, prefix, startAfter) || (skip != nil && skip(key)) {
+				continue
+			}
+			err = d.walkDir(ctx, key, prefix, startAfter, skip, fn)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasPrefix(key, prefix) || key <= startAfter || isTempFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// the file has been removed while walking
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, path.Join(dirPath, entry.Name()))
+		}
+		err = fn(key, info)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryKey returns the name of the entry as it is sorted within its directory.
+func entryKey(entry fs.DirEntry) string {
+	if entry.IsDir() {
+		return entry.Name() + "/"
+	}
+	return entry.Name()
+}
+
+// mayContain reports whether the directory identified by dirKey may contain
+// keys that start with prefix and are lexically greater than startAfter.
+func mayContain(dirKey, prefix, startAfter string) bool {
+	if !strings.HasPrefix(dirKey, prefix) && !strings.HasPrefix(prefix, dirKey) {
+		return false
+	}
+	// all keys within the directory are greater than dirKey, so they are all
+	// lower than startAfter if startAfter is greater and not within the directory
+	return startAfter <= dirKey || strings.HasPrefix(startAfter, dirKey)
+}
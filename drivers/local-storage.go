@@ -2,12 +2,21 @@ package drivers
 
 import (
 	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
+	"sync"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
 )
 
 type LocalStorage struct {
@@ -16,6 +25,22 @@ type LocalStorage struct {
 	// Permissions defines the file permissions for the files in the local storage.
 	// If not specified, the default value 0644 is used.
 	Permissions *int
+	// DirPermissions defines the permissions of the directories created for nested keys.
+	// If not specified, the default value 0755 is used.
+	DirPermissions *int
+	// SigningKey is the secret the URLs returned by SignURL are signed with.
+	// Signing URLs is only supported if SigningKey and BaseURL are set.
+	SigningKey []byte
+	// BaseURL is the URL the handler returned by SignedURLHandler is served at,
+	// e.g. "https://files.example.com/storage".
+	BaseURL string
+	// Versioning defines whether the previous versions of overwritten and deleted files
+	// are kept. Files written before versioning has been enabled have the null version ID.
+	Versioning bool
+	// Durable defines whether the directory of a written file, and every directory created
+	// for it, is flushed to stable storage as well, so that the write survives a crash of
+	// the system. The written file itself is always flushed before it is renamed into place.
+	Durable bool
 }
 
 // NewLocalStorage creates a new LocalStorage instance.
@@ -26,9 +51,30 @@ func NewLocalStorage(path string) *LocalStorage {
 	}
 }
 
-// fullPath returns the full path of the file.
-func (d LocalStorage) fullPath(file string) string {
-	return path.Join(d.Path, file)
+// Capabilities returns the optional features supported by the local storage.
+func (d LocalStorage) Capabilities() gostorage.Capability {
+	return gostorage.CapabilityContext | gostorage.CapabilityStreamRead | gostorage.CapabilityRangeRead |
+		gostorage.CapabilityStat | gostorage.CapabilityWriteOptions | gostorage.CapabilityConditionalWrite |
+		gostorage.CapabilityHierarchicalList | gostorage.CapabilityCopy | gostorage.CapabilityMove |
+		gostorage.CapabilityBatchDelete | gostorage.CapabilityPrefixDelete | d.signingCapability() |
+		d.versioningCapability() | gostorage.CapabilityMultipartUpload
+}
+
+// fullPath returns the full path of the file identified by key.
+// Keys that are not a relative slash separated path below the root directory, or that
+// resolve to a location outside of it through symbolic links, are rejected with
+// gostorage.ErrInvalidKey.
+func (d LocalStorage) fullPath(key string) (string, error) {
+	err := validateKey(key)
+	if err != nil {
+		return "", err
+	}
+	filePath := path.Join(d.Path, key)
+	err = d.checkSymlinks(filePath)
+	if err != nil {
+		return "", err
+	}
+	return filePath, nil
 }
 
 // filePermissions returns the file permissions for the files in the local storage.
@@ -40,44 +86,441 @@ func (d LocalStorage) filePermissions() fs.FileMode {
 	return 0644
 }
 
+// dirPermissions returns the permissions of the directories in the local storage.
+// If not specified, the default value 0755 is used.
+func (d LocalStorage) dirPermissions() fs.FileMode {
+	if d.DirPermissions != nil {
+		return fs.FileMode(*d.DirPermissions)
+	}
+	return 0755
+}
+
+// createParents creates the parent directories of the file identified by key.
+// The root directory itself is never created, so that a wrong root directory is reported.
+func (d LocalStorage) createParents(key string) error {
+	dir := path.Dir(key)
+	if dir == "." {
+		return nil
+	}
+	if _, err := os.Stat(d.Path); err != nil {
+		return err
+	}
+	dirPath, err := d.fullPath(dir)
+	if err != nil {
+		return err
+	}
+	return d.mkdirAll(dirPath)
+}
+
+// maxParentAttempts is the number of times withParents creates the parent directories.
+const maxParentAttempts = 5
+
+// withParents creates the parent directories of the file identified by key and calls
+// create, which creates a file within them. A concurrent delete may prune the directories
+// before the file has been created, in which case they are created again.
+func (d LocalStorage) withParents(key string, create func() error) error {
+	dirPath := path.Join(d.Path, path.Dir(key))
+	for attempt := 1; ; attempt++ {
+		// creating the directories fails as well if a parent is pruned meanwhile
+		err := d.createParents(key)
+		if err == nil {
+			err = create()
+		}
+		if attempt == maxParentAttempts || !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if _, serr := os.Stat(dirPath); serr == nil {
+			// the directory exists, so something else is missing
+			return err
+		}
+	}
+}
+
 // Read returns the value of the file identified by key.
 // If the file does not exist, an error is returned.
 func (d LocalStorage) Read(key string) (io.Reader, error) {
-	path := d.fullPath(key)
-	bts, err := ioutil.ReadFile(path)
+	return d.ReadContext(context.Background(), key)
+}
+
+// ReadContext returns the value of the file identified by key.
+// If the file does not exist, an error is returned.
+func (d LocalStorage) ReadContext(ctx context.Context, key string) (io.Reader, error) {
+	rc, err := d.ReadStreamContext(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	bts, err := ioutil.ReadAll(rc)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", err, path)
+		return nil, localStorageError("read", key, err)
 	}
 	return bytes.NewBuffer(bts), nil
 }
 
+// ReadStream returns the opened file identified by key.
+// The caller must close the returned reader.
+func (d LocalStorage) ReadStream(key string) (io.ReadCloser, error) {
+	return d.ReadStreamContext(context.Background(), key)
+}
+
+// ReadStreamContext returns the opened file identified by key.
+// Reading from the returned reader fails once the context is done.
+// The caller must close the returned reader.
+func (d LocalStorage) ReadStreamContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, _, err := d.openFile(ctx, "read", key)
+	if err != nil {
+		return nil, err
+	}
+	return &fileReader{
+		Reader: utils.ContextReader(ctx, file),
+		file:   file,
+	}, nil
+}
+
+// openFile opens the file identified by key for reading.
+// Directories are reported as files that do not exist.
+func (d LocalStorage) openFile(ctx context.Context, op, key string) (*os.File, fs.FileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, localStorageError(op, key, err)
+	}
+	path, err := d.fullPath(key)
+	if err != nil {
+		return nil, nil, localStorageError(op, key, err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, localStorageError(op, key, err)
+	}
+	fInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, localStorageError(op, key, err)
+	}
+	if fInfo.IsDir() {
+		file.Close()
+		return nil, nil, localStorageError(op, key, fmt.Errorf("%w: %s is a directory", gostorage.ErrNotExist, path))
+	}
+	return file, fInfo, nil
+}
+
 func (d LocalStorage) Write(key string, value io.Reader) error {
-	filePath := d.fullPath(key)
-	bts, err := ioutil.ReadAll(value)
+	return d.WriteContext(context.Background(), key, value)
+}
+
+func (d LocalStorage) WriteContext(ctx context.Context, key string, value io.Reader) error {
+	return d.WriteWithOptionsContext(ctx, key, value, gostorage.WriteOptions{})
+}
+
+// WriteWithOptions writes the value to the file identified by key.
+// The attributes defined by opts are stored in a sidecar file.
+func (d LocalStorage) WriteWithOptions(key string, value io.Reader, opts gostorage.WriteOptions) error {
+	return d.WriteWithOptionsContext(context.Background(), key, value, opts)
+}
+
+// WriteWithOptionsContext writes the value to the file identified by key.
+// The attributes defined by opts are stored in a sidecar file.
+// The value is streamed into a temporary file next to the file, which is renamed into place
+// once it has been flushed, so that readers never observe a partially written file.
+// If Versioning is enabled, the replaced content is kept as previous version.
+func (d LocalStorage) WriteWithOptionsContext(ctx context.Context, key string, value io.Reader, opts gostorage.WriteOptions) error {
+	if err := ctx.Err(); err != nil {
+		return localStorageError("write", key, err)
+	}
+	filePath, err := d.fullPath(key)
+	if err != nil {
+		return localStorageError("write", key, err)
+	}
+	err = validateConditions(opts)
+	if err != nil {
+		return localStorageError("write", key, err)
+	}
+	var file *os.File
+	err = d.withParents(key, func() (err error) {
+		file, err = createTemp(path.Dir(filePath))
+		return err
+	})
+	if err != nil {
+		return localStorageError("write", key, err)
+	}
+	// the value is written before the lock is acquired, as it may be large
+	tmp, etag, err := d.fillTemp(ctx, file, value)
+	if err != nil {
+		return localStorageError("write", key, err)
+	}
+	defer os.Remove(tmp)
+	err = d.commitFile(ctx, key, filePath, tmp, etag, opts)
+	if err != nil {
+		return localStorageError("write", key, err)
+	}
+	return nil
+}
+
+// commitFile replaces the named file identified by key with the temporary file, whose
+// content has the given ETag, and stores the attributes defined by opts.
+// The file is replaced while holding the lock of the local storage, which every change of a
+// file holds, so that the conditions IfNotExists and IfMatch are checked atomically.
+func (d LocalStorage) commitFile(ctx context.Context, key, filePath, tmp, etag string, opts gostorage.WriteOptions) error {
+	fInfo, err := os.Stat(tmp)
+	if err != nil {
+		return err
+	}
+	unlock, err := d.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if opts.IfNotExists {
+		// the file is still linked exclusively, as it may be created by other programs
+		if _, err := os.Lstat(filePath); err == nil {
+			return fmt.Errorf("%w: file exists", gostorage.ErrPreconditionFailed)
+		}
+	}
+	if opts.IfMatch != "" {
+		err = d.matchETag(ctx, key, filePath, opts.IfMatch)
+		if err != nil {
+			return err
+		}
+	}
+
+	md := newFileMetadata(opts)
+	if md == nil {
+		md = &fileMetadata{}
+	}
+	// the link or rename keeps the size and modification time of the temporary file
+	md.setETag(etag, fInfo)
+	restore := func() {}
+	if d.Versioning {
+		md.VersionID, err = newVersionID()
+		if err != nil {
+			return err
+		}
+		if !opts.IfNotExists {
+			restore, err = d.archiveVersion(key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// the sidecar is replaced before the content, so that a failed write never leaves the
+	// new content behind without it; until the content is replaced, its stored ETag is stale
+	previous, err := d.readMetadata(key)
+	if err != nil {
+		restore()
+		return err
+	}
+	err = d.writeMetadata(key, md)
 	if err != nil {
-		return fmt.Errorf("%w: %s", err, filePath)
+		restore()
+		return err
 	}
-	err = ioutil.WriteFile(filePath, bts, d.filePermissions())
+	err = d.commitTemp(tmp, filePath, opts.IfNotExists)
 	if err != nil {
-		return fmt.Errorf("%w: %s", err, filePath)
+		restore()
+		_ = d.writeMetadata(key, previous)
+		if opts.IfNotExists && errors.Is(err, fs.ErrExist) {
+			err = &mappedError{sentinel: gostorage.ErrPreconditionFailed, err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// matchETag returns ErrPreconditionFailed unless the named file identified by key
+// exists and its ETag equals the given ETag.
+func (d LocalStorage) matchETag(ctx context.Context, key, name, etag string) error {
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: file does not exist", gostorage.ErrPreconditionFailed)
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	md, err := d.readMetadata(key)
+	if err != nil {
+		return err
+	}
+	current, err := storedETag(ctx, md, file, fInfo)
+	if err != nil {
+		return err
+	}
+	if current != unquoteETag(etag) {
+		return fmt.Errorf("%w: etag %q does not match %q", gostorage.ErrPreconditionFailed, current, unquoteETag(etag))
 	}
 	return nil
 }
 
+// storedETag returns the ETag stored in the attributes of the file described by fInfo.
+// If it has not been stored, it is computed from the content of the file.
+func storedETag(ctx context.Context, md *fileMetadata, file *os.File, fInfo fs.FileInfo) (string, error) {
+	if etag := md.etag(fInfo); etag != "" {
+		return etag, nil
+	}
+	return contentETag(ctx, file)
+}
+
+// contentETag returns the ETag of the content, the hex encoded MD5 checksum.
+func contentETag(ctx context.Context, r io.Reader) (string, error) {
+	hash := md5.New()
+	_, err := io.Copy(hash, utils.ContextReader(ctx, r))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 func (d LocalStorage) Delete(key string) error {
-	path := d.fullPath(key)
-	err := os.Remove(path)
+	return d.DeleteContext(context.Background(), key)
+}
+
+func (d LocalStorage) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return localStorageError("delete", key, err)
+	}
+	path, err := d.fullPath(key)
 	if err != nil {
-		return fmt.Errorf("%w: %s", err, path)
+		return localStorageError("delete", key, err)
 	}
+	if d.Versioning {
+		err := d.deleteVersioned(key)
+		if err != nil {
+			return localStorageError("delete", key, err)
+		}
+		d.pruneDirs(key, "")
+		return nil
+	}
+	err = d.deleteFile(key, path)
+	if err != nil {
+		return localStorageError("delete", key, err)
+	}
+	d.pruneDirs(key, "")
 	return nil
 }
 
+// deleteFile removes the named file identified by key and its attributes.
+func (d LocalStorage) deleteFile(key, name string) error {
+	unlock, err := d.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = os.Remove(name)
+	if err != nil {
+		return err
+	}
+	return d.deleteMetadata(key)
+}
+
+// localDeleteWorkers is the number of files DeleteMany removes concurrently.
+const localDeleteWorkers = 16
+
+// DeleteMany deletes the files identified by keys concurrently.
+// The returned map contains the error of every key that could not be deleted.
+// Missing files are not an error.
+func (d LocalStorage) DeleteMany(keys []string) map[string]error {
+	return d.DeleteManyContext(context.Background(), keys)
+}
+
+// DeleteManyContext deletes the files identified by keys concurrently.
+// The returned map contains the error of every key that could not be deleted.
+// Missing files are not an error.
+func (d LocalStorage) DeleteManyContext(ctx context.Context, keys []string) map[string]error {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = map[string]error{}
+	)
+	queue := make(chan string)
+	workers := localDeleteWorkers
+	if len(keys) < workers {
+		workers = len(keys)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range queue {
+				err := d.DeleteContext(ctx, key)
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					mu.Lock()
+					errs[key] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, key := range keys {
+		queue <- key
+	}
+	close(queue)
+	wg.Wait()
+	return errs
+}
+
+// DeletePrefix deletes all files whose keys start with prefix, including the files
+// within subdirectories, and returns their keys. An empty prefix is rejected with gostorage.ErrInvalidKey.
+func (d LocalStorage) DeletePrefix(prefix string, opts gostorage.DeletePrefixOptions) ([]string, error) {
+	return d.DeletePrefixContext(context.Background(), prefix, opts)
+}
+
+// DeletePrefixContext deletes all files whose keys start with prefix, including the files
+// within subdirectories, and returns their keys. The directories that are left empty
+// are removed as well. An empty prefix is rejected with gostorage.ErrInvalidKey.
+func (d LocalStorage) DeletePrefixContext(ctx context.Context, prefix string, opts gostorage.DeletePrefixOptions) ([]string, error) {
+	keys, err := deletePrefix(ctx, d, localStorageDriverName, prefix, opts)
+	if !opts.DryRun {
+		for _, key := range keys {
+			d.pruneDirs(key, prefix)
+		}
+	}
+	return keys, err
+}
+
+// pruneDirs removes the empty parent directories of the file identified by key,
+// as long as they are below the prefix. The root directory is never removed.
+func (d LocalStorage) pruneDirs(key, prefix string) {
+	for dir := path.Dir(key); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if !strings.HasPrefix(dir+"/", prefix) {
+			return
+		}
+		dirPath, err := d.fullPath(dir)
+		if err != nil {
+			return
+		}
+		// removing a directory that is not empty fails, which ends the pruning
+		if os.Remove(dirPath) != nil {
+			return
+		}
+	}
+}
+
+// Exists reports whether the file identified by key exists.
+// A missing file is not an error, while directories are not reported as files.
 func (d LocalStorage) Exists(key string) (bool, error) {
-	path := d.fullPath(key)
+	return d.ExistsContext(context.Background(), key)
+}
+
+// ExistsContext reports whether the file identified by key exists.
+// A missing file is not an error, while directories are not reported as files.
+func (d LocalStorage) ExistsContext(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, localStorageError("exists", key, err)
+	}
+	path, err := d.fullPath(key)
+	if err != nil {
+		return false, localStorageError("exists", key, err)
+	}
 	fInfo, err := os.Stat(path)
 	if err != nil {
-		return false, fmt.Errorf("%w: %s", err, path)
+		err = localStorageError("exists", key, err)
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
 	}
 	if fInfo.IsDir() {
 		return false, nil
@@ -85,17 +528,352 @@ func (d LocalStorage) Exists(key string) (bool, error) {
 	return true, nil
 }
 
+// List returns the keys of all files below the root directory, including the files
+// within subdirectories. The keys are slash separated and sorted lexically.
 func (d LocalStorage) List() ([]string, error) {
-	files, err := ioutil.ReadDir(d.Path)
+	return d.ListContext(context.Background())
+}
+
+// ListContext returns the keys of all files below the root directory, including the files
+// within subdirectories. The keys are slash separated and sorted lexically.
+func (d LocalStorage) ListContext(ctx context.Context) ([]string, error) {
+	keys := []string{}
+	err := d.walk(ctx, "", "", nil, func(key string, info fs.FileInfo) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return []string{}, localStorageError("list", "", err)
+	}
+	return keys, nil
+}
+
+// Stat returns the metadata of the file identified by key.
+// The attributes the file has been written with are read from its sidecar file.
+// The ETag is the MD5 checksum of the file, which is stored while the file is written.
+// Only files written or modified without the driver are read completely to compute it.
+func (d LocalStorage) Stat(key string) (*gostorage.ObjectInfo, error) {
+	return d.StatContext(context.Background(), key)
+}
+
+// StatContext returns the metadata of the file identified by key.
+// The ETag is the MD5 checksum of the file, which is stored while the file is written.
+// Only files written or modified without the driver are read completely to compute it.
+func (d LocalStorage) StatContext(ctx context.Context, key string) (*gostorage.ObjectInfo, error) {
+	file, fInfo, err := d.openFile(ctx, "stat", key)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	mType, err := utils.MimeType(file)
+	if err != nil {
+		return nil, localStorageError("stat", key, err)
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, localStorageError("stat", key, err)
+	}
+	md, err := d.readMetadata(key)
+	if err != nil {
+		return nil, localStorageError("stat", key, err)
+	}
+	etag, err := storedETag(ctx, md, file, fInfo)
+	if err != nil {
+		return nil, localStorageError("stat", key, err)
+	}
+
+	info := &gostorage.ObjectInfo{
+		Key:          key,
+		Size:         fInfo.Size(),
+		LastModified: fInfo.ModTime(),
+		ContentType:  mType,
+		ETag:         etag,
+	}
+	if md != nil {
+		md.apply(info)
+	}
+	return info, nil
+}
+
+// ListPage returns a single page of the files below the root directory, including the
+// files within subdirectories. The keys of the files are slash separated.
+// Directories without any file are never returned as common prefix.
+func (d LocalStorage) ListPage(opts gostorage.ListOptions) (*gostorage.ListResult, error) {
+	return d.ListPageContext(context.Background(), opts)
+}
+
+// ListPageContext returns a single page of the files below the root directory, including the
+// files within subdirectories. The keys of the files are slash separated.
+// Directories without any file are never returned as common prefix.
+func (d LocalStorage) ListPageContext(ctx context.Context, opts gostorage.ListOptions) (*gostorage.ListResult, error) {
+	startAfter := opts.StartAfter
+	if opts.ContinuationToken != "" {
+		startAfter = opts.ContinuationToken
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = gostorage.DefaultPageSize
+	}
+
+	// commonPrefix returns the common prefix the key is grouped into, if any
+	commonPrefix := func(key string) (string, bool) {
+		if opts.Delimiter == "" || !strings.HasPrefix(key, opts.Prefix) {
+			return "", false
+		}
+		i := strings.Index(key[len(opts.Prefix):], opts.Delimiter)
+		if i < 0 {
+			return "", false
+		}
+		return key[:len(opts.Prefix)+i+len(opts.Delimiter)], true
+	}
+
+	res := &gostorage.ListResult{}
+	// the keys and common prefixes are visited in lexical order,
+	// so last is the greatest key or common prefix of the page
+	last := ""
+	count := 0
+	skip := func(dirKey string) bool {
+		// all files within the directory would be grouped into a common prefix
+		// that has been returned already
+		cp, ok := commonPrefix(dirKey)
+		return ok && (cp == startAfter || cp == last)
+	}
+	err := d.walk(ctx, opts.Prefix, startAfter, skip, func(key string, info fs.FileInfo) error {
+		cp, grouped := commonPrefix(key)
+		if grouped && (cp == startAfter || cp == last) {
+			return nil
+		}
+		if count == pageSize {
+			// there is at least one more entry, so the listing continues after the last one
+			res.NextContinuationToken = last
+			return errStopWalk
+		}
+		count++
+		if grouped {
+			res.CommonPrefixes = append(res.CommonPrefixes, cp)
+			last = cp
+			return nil
+		}
+		res.Objects = append(res.Objects, gostorage.ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		last = key
+		return nil
+	})
+	if err != nil {
+		return nil, localStorageError("list", opts.Prefix, err)
+	}
+	return res, nil
+}
+
+// Copy copies the file src including its attributes to dst.
+func (d LocalStorage) Copy(src, dst string) error {
+	return d.CopyContext(context.Background(), src, dst)
+}
+
+// CopyContext copies the file src including its attributes to dst.
+// The file src is hard linked to a temporary file next to dst, which is committed like
+// a written file, so that the content is not copied. If the file system does not support
+// linking it, the content is streamed from src to dst instead.
+func (d LocalStorage) CopyContext(ctx context.Context, src, dst string) error {
+	file, fInfo, err := d.openFile(ctx, "copy", src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	md, err := d.readMetadata(src)
+	if err != nil {
+		return localStorageError("copy", src, err)
+	}
+	if src == dst {
+		// the file would only be replaced by itself
+		return nil
+	}
+	dstPath, err := d.fullPath(dst)
+	if err != nil {
+		return localStorageError("copy", src, err)
+	}
+	etag, err := storedETag(ctx, md, file, fInfo)
+	if err != nil {
+		return localStorageError("copy", src, err)
+	}
+
+	tmp, err := d.linkTemp(dst, path.Dir(dstPath), file.Name())
+	if errors.Is(err, fs.ErrNotExist) {
+		return localStorageError("copy", src, err)
+	}
+	if err != nil {
+		// e.g. if dst is on another file system mounted below the root directory
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return localStorageError("copy", src, err)
+		}
+		return d.WriteWithOptionsContext(ctx, dst, file, md.writeOptions())
+	}
+	defer os.Remove(tmp)
+	err = d.commitFile(ctx, dst, dstPath, tmp, etag, md.writeOptions())
+	if err != nil {
+		return localStorageError("copy", src, err)
+	}
+	return nil
+}
+
+// Move renames the file src including its attributes to dst.
+func (d LocalStorage) Move(src, dst string) error {
+	return d.MoveContext(context.Background(), src, dst)
+}
+
+// MoveContext renames the file src including its attributes to dst.
+func (d LocalStorage) MoveContext(ctx context.Context, src, dst string) error {
+	if err := ctx.Err(); err != nil {
+		return localStorageError("move", src, err)
+	}
+	srcPath, err := d.fullPath(src)
+	if err != nil {
+		return localStorageError("move", src, err)
+	}
+	fInfo, err := os.Stat(srcPath)
+	if err != nil {
+		return localStorageError("move", src, err)
+	}
+	if fInfo.IsDir() {
+		return localStorageError("move", src, fmt.Errorf("%w: %s is a directory", gostorage.ErrNotExist, srcPath))
+	}
+	dstPath, err := d.fullPath(dst)
 	if err != nil {
-		return []string{}, fmt.Errorf("%w: %s", err, d.Path)
+		return localStorageError("move", src, err)
+	}
+	if srcPath == dstPath {
+		return nil
 	}
-	fileName := []string{}
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	if d.Versioning {
+		// renaming would neither keep the replaced version of dst
+		// nor mark src as deleted
+		err = d.CopyContext(ctx, src, dst)
+		if err != nil {
+			return err
 		}
-		fileName = append(fileName, file.Name())
+		return d.DeleteContext(ctx, src)
+	}
+	err = d.renameFile(src, dst, srcPath, dstPath)
+	if err != nil {
+		return localStorageError("move", src, err)
+	}
+	d.pruneDirs(src, "")
+	return nil
+}
+
+// renameFile renames the named file identified by src including its attributes
+// to the named file identified by dst.
+func (d LocalStorage) renameFile(src, dst, srcPath, dstPath string) error {
+	unlock, err := d.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	md, err := d.readMetadata(src)
+	if err != nil {
+		return err
+	}
+	err = d.withParents(dst, func() error {
+		return os.Rename(srcPath, dstPath)
+	})
+	if err != nil {
+		return err
+	}
+	err = d.writeMetadata(dst, md)
+	if err != nil {
+		return err
+	}
+	return d.deleteMetadata(src)
+}
+
+// ReadRange returns the byte range of the file identified by key.
+// The caller must close the returned reader.
+func (d LocalStorage) ReadRange(key string, offset, length int64) (io.ReadCloser, error) {
+	return d.ReadRangeContext(context.Background(), key, offset, length)
+}
+
+// ReadRangeContext returns the byte range of the file identified by key.
+// Reading from the returned reader fails once the context is done.
+// The caller must close the returned reader.
+func (d LocalStorage) ReadRangeContext(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	err := validateRange(offset, length)
+	if err != nil {
+		return nil, localStorageError("read", key, err)
+	}
+	file, fInfo, err := d.openFile(ctx, "read", key)
+	if err != nil {
+		return nil, err
+	}
+	start, n, err := resolveRange(offset, length, fInfo.Size())
+	if err != nil {
+		file.Close()
+		return nil, localStorageError("read", key, err)
+	}
+	_, err = file.Seek(start, io.SeekStart)
+	if err != nil {
+		file.Close()
+		return nil, localStorageError("read", key, err)
+	}
+	return &fileReader{
+		Reader: io.LimitReader(utils.ContextReader(ctx, file), n),
+		file:   file,
+	}, nil
+}
+
+// OpenReaderAt returns the opened file identified by key for random access.
+// The caller must close the returned ReadAtCloser.
+func (d LocalStorage) OpenReaderAt(key string) (gostorage.ReadAtCloser, error) {
+	return d.OpenReaderAtContext(context.Background(), key)
+}
+
+// OpenReaderAtContext returns the opened file identified by key for random access.
+// Reading from the returned ReadAtCloser fails once the context is done.
+// The caller must close the returned ReadAtCloser.
+func (d LocalStorage) OpenReaderAtContext(ctx context.Context, key string) (gostorage.ReadAtCloser, error) {
+	file, fInfo, err := d.openFile(ctx, "read", key)
+	if err != nil {
+		return nil, err
+	}
+	return &fileReaderAt{
+		ctx:  ctx,
+		file: file,
+		size: fInfo.Size(),
+	}, nil
+}
+
+// fileReader reads from an opened file and closes it once the reader is closed.
+type fileReader struct {
+	io.Reader
+	file *os.File
+}
+
+func (f *fileReader) Close() error {
+	return f.file.Close()
+}
+
+// fileReaderAt provides random access to an opened file.
+type fileReaderAt struct {
+	ctx  context.Context
+	file *os.File
+	size int64
+}
+
+func (f *fileReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.ctx.Err(); err != nil {
+		return 0, err
 	}
-	return fileName, nil
+	return f.file.ReadAt(p, off)
+}
+
+func (f *fileReaderAt) Size() int64 {
+	return f.size
+}
+
+func (f *fileReaderAt) Close() error {
+	return f.file.Close()
 }
@@ -0,0 +1,40 @@
+//go:build go1.18
+// +build go1.18
+
+package drivers
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"testing"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+func FuzzLocalStorage_fullPath(f *testing.F) {
+	for _, key := range []string{
+		"test.txt", "dir/test.txt", "../etc/passwd", "dir/../../test.txt", "/etc/passwd",
+		"test\x00.txt", ".gostorage/lock", "dir//test.txt", "./test.txt", "dir/", "..",
+	} {
+		f.Add(key)
+	}
+	d := LocalStorage{
+		Path: "/tmp/test-fuzz",
+	}
+	f.Fuzz(func(t *testing.T, key string) {
+		got, err := d.fullPath(key)
+		if err != nil {
+			if !errors.Is(err, gostorage.ErrInvalidKey) {
+				t.Errorf("LocalStorage.fullPath(%q) error = %v, want %v", key, err, gostorage.ErrInvalidKey)
+			}
+			return
+		}
+		if !strings.HasPrefix(got, d.Path+"/") || path.Clean(got) != got {
+			t.Errorf("LocalStorage.fullPath(%q) = %q, want a path below %s", key, got, d.Path)
+		}
+		if strings.HasPrefix(got, path.Join(d.Path, internalDir)+"/") || strings.IndexByte(got, 0) >= 0 {
+			t.Errorf("LocalStorage.fullPath(%q) = %q, want no reserved path", key, got)
+		}
+	})
+}
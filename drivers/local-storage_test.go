@@ -2,11 +2,24 @@ package drivers
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"io/fs"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
 )
 
 func TestNewLocalStorage(t *testing.T) {
@@ -46,10 +59,11 @@ func TestLocalStorage_fullPath(t *testing.T) {
 		file string
 	}
 	tests := []struct {
-		name   string
-		fields fields
-		args   args
-		want   string
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr error
 	}{
 		{
 			name: "should return full path /tmp/test",
@@ -73,6 +87,106 @@ func TestLocalStorage_fullPath(t *testing.T) {
 			},
 			want: "/var/lib/test/test.txt",
 		},
+		{
+			name: "nested key",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "invoices/2024/a..b.pdf",
+			},
+			want: "/tmp/test/invoices/2024/a..b.pdf",
+		},
+		{
+			name: "path traversal",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "../../etc/passwd",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "path traversal within key",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "dir/../../test2/test.txt",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "absolute path",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "/etc/passwd",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "NUL byte",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "test.txt\x00.pdf",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "empty key",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "empty path element",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "dir//test.txt",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "directory",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "dir/",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "internal directory",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: internalDir + "/lock",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "temporary file",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			args: args{
+				file: "dir/" + tempFilePrefix + "123",
+			},
+			wantErr: gostorage.ErrInvalidKey,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -80,7 +194,12 @@ func TestLocalStorage_fullPath(t *testing.T) {
 				Path:        tt.fields.Path,
 				Permissions: tt.fields.Permissions,
 			}
-			if got := d.fullPath(tt.args.file); got != tt.want {
+			got, err := d.fullPath(tt.args.file)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LocalStorage.fullPath() error = %v, want %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
 				t.Errorf("LocalStorage.fullPath() = %v, want %v", got, tt.want)
 			}
 		})
@@ -429,7 +548,7 @@ func TestLocalStorage_Write(t *testing.T) {
 
 			// we only the value if we do not expect an error
 			if tt.wantErr == false {
-				bts, err := ioutil.ReadFile(d.fullPath(tt.args.key))
+				bts, err := ioutil.ReadFile(path.Join(tt.fields.Path, tt.args.key))
 				if err != nil {
 					t.Errorf("TestLocalStorage_Write() error reading file: %v", err)
 				}
@@ -614,7 +733,7 @@ func TestLocalStorage_Exists(t *testing.T) {
 				},
 			},
 			want:    false,
-			wantErr: true,
+			wantErr: false,
 		},
 		{
 			name: "empty file",
@@ -687,7 +806,7 @@ func TestLocalStorage_Exists(t *testing.T) {
 				postCondition: func() {},
 			},
 			want:    false,
-			wantErr: true,
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
@@ -830,6 +949,40 @@ func TestLocalStorage_List(t *testing.T) {
 			want:    []string{"test1.txt", "test2.txt"},
 			wantErr: false,
 		},
+		{
+			name: "nested files",
+			fields: fields{
+				Path: "/tmp/test",
+			},
+			cond: conditions{
+				preCondition: func() {
+					err := os.MkdirAll("/tmp/test/invoices/2024/01", 0755)
+					if err != nil {
+						t.Errorf("error creating directory: %v", err)
+					}
+					err = os.MkdirAll("/tmp/test/empty", 0755)
+					if err != nil {
+						t.Errorf("error creating directory: %v", err)
+					}
+					err = ioutil.WriteFile("/tmp/test/invoices/2024/01/a.pdf", []byte("a"), 0644)
+					if err != nil {
+						t.Errorf("TestLocalStorage_Read() preCondition 1: %v", err)
+					}
+					err = ioutil.WriteFile("/tmp/test/test.txt", []byte("test"), 0644)
+					if err != nil {
+						t.Errorf("TestLocalStorage_Read() preCondition 2: %v", err)
+					}
+				},
+				postCondition: func() {
+					err := os.RemoveAll("/tmp/test")
+					if err != nil {
+						t.Errorf("TestLocalStorage_Read() postCondition: %v", err)
+					}
+				},
+			},
+			want:    []string{"invoices/2024/01/a.pdf", "test.txt"},
+			wantErr: false,
+		},
 		{
 			name: "directory not exists",
 			fields: fields{
@@ -862,3 +1015,1960 @@ func TestLocalStorage_List(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalStorage_cancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		call func(d LocalStorage) error
+	}{
+		{
+			name: "ReadContext",
+			call: func(d LocalStorage) error {
+				_, err := d.ReadContext(ctx, "test.txt")
+				return err
+			},
+		},
+		{
+			name: "WriteContext",
+			call: func(d LocalStorage) error {
+				return d.WriteContext(ctx, "test.txt", strings.NewReader("test"))
+			},
+		},
+		{
+			name: "DeleteContext",
+			call: func(d LocalStorage) error {
+				return d.DeleteContext(ctx, "test.txt")
+			},
+		},
+		{
+			name: "ExistsContext",
+			call: func(d LocalStorage) error {
+				_, err := d.ExistsContext(ctx, "test.txt")
+				return err
+			},
+		},
+		{
+			name: "ListContext",
+			call: func(d LocalStorage) error {
+				_, err := d.ListContext(ctx)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+			err = ioutil.WriteFile("/tmp/test/test.txt", []byte("test"), 0644)
+			if err != nil {
+				t.Errorf("error creating file: %v", err)
+			}
+
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			if err := tt.call(d); !errors.Is(err, context.Canceled) {
+				t.Errorf("LocalStorage.%s() error = %v, want %v", tt.name, err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_ReadStream(t *testing.T) {
+	type args struct {
+		key string
+	}
+	type conditions struct {
+		preCondition  func()
+		postCondition func()
+	}
+	tests := []struct {
+		name    string
+		args    args
+		cond    conditions
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "file found",
+			args: args{
+				key: "test.txt",
+			},
+			cond: conditions{
+				preCondition: func() {
+					err := os.MkdirAll("/tmp/test", 0755)
+					if err != nil {
+						t.Errorf("error creating directory: %v", err)
+					}
+					err = ioutil.WriteFile("/tmp/test/test.txt", []byte("test"), 0644)
+					if err != nil {
+						t.Errorf("TestLocalStorage_ReadStream() preCondition: %v", err)
+					}
+				},
+				postCondition: func() {
+					err := os.RemoveAll("/tmp/test")
+					if err != nil {
+						t.Errorf("TestLocalStorage_ReadStream() postCondition: %v", err)
+					}
+				},
+			},
+			want:    []byte("test"),
+			wantErr: false,
+		},
+		{
+			name: "file not found",
+			args: args{
+				key: "test.txt",
+			},
+			cond: conditions{
+				preCondition: func() {
+					err := os.MkdirAll("/tmp/test", 0755)
+					if err != nil {
+						t.Errorf("error creating directory: %v", err)
+					}
+				},
+				postCondition: func() {
+					err := os.RemoveAll("/tmp/test")
+					if err != nil {
+						t.Errorf("TestLocalStorage_ReadStream() postCondition: %v", err)
+					}
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cond.preCondition()
+			defer tt.cond.postCondition()
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			got, err := d.ReadStream(tt.args.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LocalStorage.ReadStream() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got == nil {
+				return
+			}
+			defer got.Close()
+
+			bts, err := ioutil.ReadAll(got)
+			if err != nil {
+				t.Errorf("LocalStorage.ReadStream() error reading file: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(bts, tt.want) {
+				t.Errorf("LocalStorage.ReadStream() = %v, want %v", bts, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_ReadStream_largeFile(t *testing.T) {
+	const size int64 = 256 << 20
+
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+	// a sparse file is sufficient, the content itself does not matter
+	file, err := os.Create("/tmp/test/large.bin")
+	if err != nil {
+		t.Errorf("error creating file: %v", err)
+		return
+	}
+	err = file.Truncate(size)
+	file.Close()
+	if err != nil {
+		t.Errorf("error truncating file: %v", err)
+		return
+	}
+
+	d := LocalStorage{
+		Path: "/tmp/test",
+	}
+	var n int64
+	allocated := allocatedBytes(func() {
+		rc, err := d.ReadStream("large.bin")
+		if err != nil {
+			t.Errorf("LocalStorage.ReadStream() error = %v", err)
+			return
+		}
+		defer rc.Close()
+		n, err = io.Copy(ioutil.Discard, rc)
+		if err != nil {
+			t.Errorf("LocalStorage.ReadStream() error reading file: %v", err)
+		}
+	})
+	if n != size {
+		t.Errorf("LocalStorage.ReadStream() read %d bytes, want %d", n, size)
+	}
+	if allocated > maxStreamAllocation {
+		t.Errorf("LocalStorage.ReadStream() allocated %d bytes, want at most %d", allocated, maxStreamAllocation)
+	}
+}
+
+// zeroReader returns an endless stream of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestLocalStorage_Write_largeFile(t *testing.T) {
+	const size int64 = 64 << 20
+
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	d := LocalStorage{
+		Path: "/tmp/test",
+	}
+	allocated := allocatedBytes(func() {
+		err := d.Write("large.bin", io.LimitReader(zeroReader{}, size))
+		if err != nil {
+			t.Errorf("LocalStorage.Write() error = %v", err)
+		}
+	})
+	fInfo, err := os.Stat("/tmp/test/large.bin")
+	if err != nil {
+		t.Errorf("LocalStorage.Write() did not create the file: %v", err)
+	} else if fInfo.Size() != size {
+		t.Errorf("LocalStorage.Write() wrote %d bytes, want %d", fInfo.Size(), size)
+	}
+	if allocated > maxStreamAllocation {
+		t.Errorf("LocalStorage.Write() allocated %d bytes, want at most %d", allocated, maxStreamAllocation)
+	}
+}
+
+func Test_missingDirs(t *testing.T) {
+	err := os.MkdirAll("/tmp/test/dir", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+	tests := []struct {
+		name string
+		dir  string
+		want []string
+	}{
+		{
+			name: "existing directory",
+			dir:  "/tmp/test/dir",
+			want: []string{},
+		},
+		{
+			name: "missing directory",
+			dir:  "/tmp/test/dir/a",
+			want: []string{"/tmp/test/dir/a"},
+		},
+		{
+			name: "missing parents",
+			dir:  "/tmp/test/a/b/c",
+			want: []string{"/tmp/test/a/b/c", "/tmp/test/a/b", "/tmp/test/a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := missingDirs(tt.dir)
+			if err != nil {
+				t.Errorf("missingDirs() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_Write_durableNestedKey(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	d := LocalStorage{
+		Path:    "/tmp/test",
+		Durable: true,
+	}
+	err = d.WriteWithOptions("a/b/test.txt", strings.NewReader("test"), gostorage.WriteOptions{ContentType: "text/plain"})
+	if err != nil {
+		t.Errorf("LocalStorage.WriteWithOptions() error = %v", err)
+		return
+	}
+	got := readString(t, d, "a/b/test.txt")
+	if got != "test" {
+		t.Errorf("LocalStorage.Read() = %q, want %q", got, "test")
+	}
+}
+
+func TestLocalStorage_Write_atomic(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		value   io.Reader
+		durable bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "written",
+			ctx:   context.Background(),
+			value: strings.NewReader("current"),
+			want:  "current",
+		},
+		{
+			name:    "durable",
+			ctx:     context.Background(),
+			value:   strings.NewReader("current"),
+			durable: true,
+			want:    "current",
+		},
+		{
+			name:    "failing reader",
+			ctx:     context.Background(),
+			value:   io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("broken"))),
+			want:    "previous",
+			wantErr: true,
+		},
+		{
+			name:    "canceled context",
+			ctx:     canceled,
+			value:   strings.NewReader("current"),
+			want:    "previous",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+			err = ioutil.WriteFile("/tmp/test/test.txt", []byte("previous"), 0644)
+			if err != nil {
+				t.Errorf("error creating file: %v", err)
+			}
+
+			d := LocalStorage{
+				Path:    "/tmp/test",
+				Durable: tt.durable,
+			}
+			err = d.WriteContext(tt.ctx, "test.txt", tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LocalStorage.WriteContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			bts, err := ioutil.ReadFile("/tmp/test/test.txt")
+			if err != nil || string(bts) != tt.want {
+				t.Errorf("LocalStorage.WriteContext() left %q, %v, want %q", bts, err, tt.want)
+			}
+			// no temporary file is left behind
+			entries, err := os.ReadDir("/tmp/test")
+			if err != nil {
+				t.Errorf("error reading directory: %v", err)
+			}
+			for _, entry := range entries {
+				if entry.Name() != "test.txt" && entry.Name() != internalDir {
+					t.Errorf("LocalStorage.WriteContext() left %s", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestLocalStorage_List_tempFiles(t *testing.T) {
+	err := os.MkdirAll("/tmp/test/dir", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+	// temporary files of writes that are still running
+	for _, name := range []string{"test.txt", tempFilePrefix + "123", "dir/" + tempFilePrefix + "456"} {
+		err = ioutil.WriteFile(path.Join("/tmp/test", name), []byte("test"), 0644)
+		if err != nil {
+			t.Errorf("error creating file: %v", err)
+		}
+	}
+
+	d := LocalStorage{
+		Path: "/tmp/test",
+	}
+	keys, err := d.List()
+	if err != nil || !reflect.DeepEqual(keys, []string{"test.txt"}) {
+		t.Errorf("LocalStorage.List() = %v, %v, want %v", keys, err, []string{"test.txt"})
+	}
+	res, err := d.ListPage(gostorage.ListOptions{})
+	if err != nil {
+		t.Fatalf("LocalStorage.ListPage() error = %v", err)
+	}
+	if len(res.Objects) != 1 || res.Objects[0].Key != "test.txt" {
+		t.Errorf("LocalStorage.ListPage() = %+v, want only test.txt", res.Objects)
+	}
+}
+
+func TestLocalStorage_Stat(t *testing.T) {
+	type args struct {
+		key string
+	}
+	type conditions struct {
+		preCondition  func()
+		postCondition func()
+	}
+	tests := []struct {
+		name    string
+		args    args
+		cond    conditions
+		want    *gostorage.ObjectInfo
+		wantErr bool
+	}{
+		{
+			name: "file found",
+			args: args{
+				key: "test.txt",
+			},
+			cond: conditions{
+				preCondition: func() {
+					err := os.MkdirAll("/tmp/test", 0755)
+					if err != nil {
+						t.Errorf("error creating directory: %v", err)
+					}
+					err = ioutil.WriteFile("/tmp/test/test.txt", []byte("test"), 0644)
+					if err != nil {
+						t.Errorf("TestLocalStorage_Stat() preCondition: %v", err)
+					}
+				},
+				postCondition: func() {
+					err := os.RemoveAll("/tmp/test")
+					if err != nil {
+						t.Errorf("TestLocalStorage_Stat() postCondition: %v", err)
+					}
+				},
+			},
+			want: &gostorage.ObjectInfo{
+				Key:         "test.txt",
+				Size:        4,
+				ContentType: "text/plain; charset=utf-8",
+				ETag:        "098f6bcd4621d373cade4e832627b4f6",
+			},
+			wantErr: false,
+		},
+		{
+			name: "file not found",
+			args: args{
+				key: "test.txt",
+			},
+			cond: conditions{
+				preCondition: func() {
+					err := os.MkdirAll("/tmp/test", 0755)
+					if err != nil {
+						t.Errorf("error creating directory: %v", err)
+					}
+				},
+				postCondition: func() {
+					err := os.RemoveAll("/tmp/test")
+					if err != nil {
+						t.Errorf("TestLocalStorage_Stat() postCondition: %v", err)
+					}
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "key is a directory",
+			args: args{
+				key: "dir",
+			},
+			cond: conditions{
+				preCondition: func() {
+					err := os.MkdirAll("/tmp/test/dir", 0755)
+					if err != nil {
+						t.Errorf("error creating directory: %v", err)
+					}
+				},
+				postCondition: func() {
+					err := os.RemoveAll("/tmp/test")
+					if err != nil {
+						t.Errorf("TestLocalStorage_Stat() postCondition: %v", err)
+					}
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cond.preCondition()
+			defer tt.cond.postCondition()
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			got, err := d.Stat(tt.args.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LocalStorage.Stat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got == nil {
+				return
+			}
+			if got.LastModified.IsZero() {
+				t.Errorf("LocalStorage.Stat() LastModified is zero")
+			}
+			got.LastModified = time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LocalStorage.Stat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_Stat_storedETag(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(name string) error
+		wantETag string
+	}{
+		{
+			name:     "unmodified file",
+			modify:   func(name string) error { return nil },
+			wantETag: "098f6bcd4621d373cade4e832627b4f6",
+		},
+		{
+			// the stored ETag is used, so the content is not read
+			name: "content replaced with the same size and modification time",
+			modify: func(name string) error {
+				fInfo, err := os.Stat(name)
+				if err != nil {
+					return err
+				}
+				err = ioutil.WriteFile(name, []byte("abcd"), 0644)
+				if err != nil {
+					return err
+				}
+				return os.Chtimes(name, fInfo.ModTime(), fInfo.ModTime())
+			},
+			wantETag: "098f6bcd4621d373cade4e832627b4f6",
+		},
+		{
+			name: "file modified externally",
+			modify: func(name string) error {
+				return ioutil.WriteFile(name, []byte("modified"), 0644)
+			},
+			wantETag: "9ae73c65f418e6f79ceb4f0e4a4b98d5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			err = d.Write("test.txt", strings.NewReader("test"))
+			if err != nil {
+				t.Errorf("LocalStorage.Write() error = %v", err)
+				return
+			}
+			err = tt.modify("/tmp/test/test.txt")
+			if err != nil {
+				t.Errorf("error modifying file: %v", err)
+				return
+			}
+			got, err := d.Stat("test.txt")
+			if err != nil {
+				t.Errorf("LocalStorage.Stat() error = %v", err)
+				return
+			}
+			if got.ETag != tt.wantETag {
+				t.Errorf("LocalStorage.Stat() ETag = %v, want %v", got.ETag, tt.wantETag)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_WriteWithOptions(t *testing.T) {
+	type args struct {
+		key   string
+		value io.Reader
+		opts  gostorage.WriteOptions
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *gostorage.ObjectInfo
+		wantErr bool
+	}{
+		{
+			name: "with attributes",
+			args: args{
+				key:   "test.txt",
+				value: strings.NewReader("test"),
+				opts: gostorage.WriteOptions{
+					ContentType:        "application/x-test",
+					CacheControl:       "no-cache",
+					ContentDisposition: "attachment",
+					ContentEncoding:    "identity",
+					Metadata: map[string]string{
+						"Owner": "tester",
+					},
+				},
+			},
+			want: &gostorage.ObjectInfo{
+				Key:                "test.txt",
+				Size:               4,
+				ContentType:        "application/x-test",
+				CacheControl:       "no-cache",
+				ContentDisposition: "attachment",
+				ContentEncoding:    "identity",
+				ETag:               "098f6bcd4621d373cade4e832627b4f6",
+				Metadata: map[string]string{
+					"owner": "tester",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "without attributes",
+			args: args{
+				key:   "test.txt",
+				value: strings.NewReader("test"),
+				opts:  gostorage.WriteOptions{},
+			},
+			want: &gostorage.ObjectInfo{
+				Key:         "test.txt",
+				Size:        4,
+				ContentType: "text/plain; charset=utf-8",
+				ETag:        "098f6bcd4621d373cade4e832627b4f6",
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			// write the file with attributes first to verify that they are replaced
+			err = d.WriteWithOptions(tt.args.key, strings.NewReader("previous"), gostorage.WriteOptions{
+				Metadata: map[string]string{"previous": "true"},
+			})
+			if err != nil {
+				t.Errorf("LocalStorage.WriteWithOptions() error = %v", err)
+				return
+			}
+
+			err = d.WriteWithOptions(tt.args.key, tt.args.value, tt.args.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LocalStorage.WriteWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got, err := d.Stat(tt.args.key)
+			if err != nil {
+				t.Errorf("LocalStorage.Stat() error = %v", err)
+				return
+			}
+			got.LastModified = time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LocalStorage.Stat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_WriteWithOptions_sidecar(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(d LocalStorage) error
+		key       string
+		wantErr   bool
+		wantValue string
+	}{
+		{
+			name: "key named like the sidecar of another key",
+			setup: func(d LocalStorage) error {
+				return d.WriteWithOptions("test", strings.NewReader("other"), gostorage.WriteOptions{ContentType: "application/x-test"})
+			},
+			key:       "test.json/nested.txt",
+			wantErr:   false,
+			wantValue: "new",
+		},
+		{
+			name: "sidecar can not be written",
+			setup: func(d LocalStorage) error {
+				err := os.MkdirAll("/tmp/test/.gostorage", 0755)
+				if err != nil {
+					return err
+				}
+				return ioutil.WriteFile("/tmp/test/.gostorage/metadata", []byte("not a directory"), 0644)
+			},
+			key:       "test",
+			wantErr:   true,
+			wantValue: "previous",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			err = ioutil.WriteFile(path.Join(d.Path, tt.key), []byte("previous"), 0644)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("error creating file: %v", err)
+				return
+			}
+			err = tt.setup(d)
+			if err != nil {
+				t.Errorf("setup error = %v", err)
+				return
+			}
+
+			err = d.WriteWithOptions(tt.key, strings.NewReader("new"), gostorage.WriteOptions{ContentType: "application/x-new"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LocalStorage.WriteWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got, err := ioutil.ReadFile(path.Join(d.Path, tt.key))
+			if err != nil {
+				t.Errorf("error reading file: %v", err)
+				return
+			}
+			if string(got) != tt.wantValue {
+				t.Errorf("LocalStorage.WriteWithOptions() wrote %q, want %q", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_WriteWithOptions_ifNotExists(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	d := LocalStorage{
+		Path: "/tmp/test",
+	}
+	err = d.WriteWithOptions("test.txt", strings.NewReader("test"), gostorage.WriteOptions{ContentType: "application/x-test"})
+	if err != nil {
+		t.Fatalf("LocalStorage.WriteWithOptions() error = %v", err)
+	}
+	// the failed write must not touch the attributes of the existing file
+	err = d.WriteWithOptions("test.txt", strings.NewReader("changed"), gostorage.WriteOptions{ContentType: "application/x-changed", IfNotExists: true})
+	if !errors.Is(err, gostorage.ErrPreconditionFailed) {
+		t.Errorf("LocalStorage.WriteWithOptions() error = %v, want %v", err, gostorage.ErrPreconditionFailed)
+	}
+	info, err := d.Stat("test.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.Stat() error = %v", err)
+	}
+	if info.ContentType != "application/x-test" {
+		t.Errorf("LocalStorage.Stat() ContentType = %q, want %q", info.ContentType, "application/x-test")
+	}
+}
+
+func TestLocalStorage_ListPage(t *testing.T) {
+	type args struct {
+		opts gostorage.ListOptions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      []string
+		wantPages int
+	}{
+		{
+			name: "all files in pages of two",
+			args: args{
+				opts: gostorage.ListOptions{PageSize: 2},
+			},
+			want:      []string{"a.txt", "b.txt", "c-f.txt", "c/d.txt", "c/e.txt"},
+			wantPages: 3,
+		},
+		{
+			name: "prefix",
+			args: args{
+				opts: gostorage.ListOptions{Prefix: "c/"},
+			},
+			want:      []string{"c/d.txt", "c/e.txt"},
+			wantPages: 1,
+		},
+		{
+			name: "start after",
+			args: args{
+				opts: gostorage.ListOptions{StartAfter: "c-f.txt", PageSize: 1},
+			},
+			want:      []string{"c/d.txt", "c/e.txt"},
+			wantPages: 2,
+		},
+		{
+			name: "no match",
+			args: args{
+				opts: gostorage.ListOptions{Prefix: "x"},
+			},
+			want:      []string{},
+			wantPages: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test/c", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+			for _, file := range []string{"a.txt", "b.txt", "c-f.txt", "c/d.txt", "c/e.txt"} {
+				err = ioutil.WriteFile("/tmp/test/"+file, []byte("test"), 0644)
+				if err != nil {
+					t.Errorf("error creating file: %v", err)
+				}
+			}
+
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			// the sidecar files of the driver must not be listed
+			err = d.WriteWithOptions("a.txt", strings.NewReader("test"), gostorage.WriteOptions{ContentType: "text/plain"})
+			if err != nil {
+				t.Errorf("LocalStorage.WriteWithOptions() error = %v", err)
+			}
+
+			got, pages := listPages(t, d, tt.args.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LocalStorage.ListPage() = %v, want %v", got, tt.want)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("LocalStorage.ListPage() pages = %d, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_Copy(t *testing.T) {
+	type args struct {
+		src string
+		dst string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     []byte
+		wantInfo *gostorage.ObjectInfo
+		wantErr  bool
+	}{
+		{
+			name: "copy with attributes",
+			args: args{
+				src: "src.txt",
+				dst: "dst.txt",
+			},
+			want: []byte("test"),
+			wantInfo: &gostorage.ObjectInfo{
+				Key:         "dst.txt",
+				Size:        4,
+				ContentType: "application/x-test",
+				ETag:        "098f6bcd4621d373cade4e832627b4f6",
+				Metadata:    map[string]string{"owner": "tester"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "copy onto itself",
+			args: args{
+				src: "src.txt",
+				dst: "src.txt",
+			},
+			want: []byte("test"),
+			wantInfo: &gostorage.ObjectInfo{
+				Key:         "src.txt",
+				Size:        4,
+				ContentType: "application/x-test",
+				ETag:        "098f6bcd4621d373cade4e832627b4f6",
+				Metadata:    map[string]string{"owner": "tester"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "source not found",
+			args: args{
+				src: "missing.txt",
+				dst: "dst.txt",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			err = d.WriteWithOptions("src.txt", strings.NewReader("test"), gostorage.WriteOptions{
+				ContentType: "application/x-test",
+				Metadata:    map[string]string{"owner": "tester"},
+			})
+			if err != nil {
+				t.Errorf("LocalStorage.WriteWithOptions() error = %v", err)
+				return
+			}
+
+			err = d.Copy(tt.args.src, tt.args.dst)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LocalStorage.Copy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			bts, err := ioutil.ReadFile("/tmp/test/" + tt.args.dst)
+			if err != nil {
+				t.Errorf("error reading file: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(bts, tt.want) {
+				t.Errorf("LocalStorage.Copy() content = %s, want %s", bts, tt.want)
+			}
+			info, err := d.Stat(tt.args.dst)
+			if err != nil {
+				t.Errorf("LocalStorage.Stat() error = %v", err)
+				return
+			}
+			info.LastModified = time.Time{}
+			if !reflect.DeepEqual(info, tt.wantInfo) {
+				t.Errorf("LocalStorage.Stat() = %+v, want %+v", info, tt.wantInfo)
+			}
+			if _, err := os.Stat("/tmp/test/src.txt"); err != nil {
+				t.Errorf("LocalStorage.Copy() removed the source: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_Copy_hardLink(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	d := LocalStorage{
+		Path: "/tmp/test",
+	}
+	err = d.Write("src.txt", strings.NewReader("test"))
+	if err != nil {
+		t.Fatalf("LocalStorage.Write() error = %v", err)
+	}
+	err = d.Copy("src.txt", "dir/dst.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.Copy() error = %v", err)
+	}
+	srcInfo, err := os.Stat("/tmp/test/src.txt")
+	if err != nil {
+		t.Fatalf("error reading source: %v", err)
+	}
+	dstInfo, err := os.Stat("/tmp/test/dir/dst.txt")
+	if err != nil {
+		t.Fatalf("error reading destination: %v", err)
+	}
+	if !os.SameFile(srcInfo, dstInfo) {
+		t.Errorf("LocalStorage.Copy() copied the content instead of linking the file")
+	}
+
+	// replacing the source keeps the copy
+	err = d.Write("src.txt", strings.NewReader("changed"))
+	if err != nil {
+		t.Fatalf("LocalStorage.Write() error = %v", err)
+	}
+	info, err := d.Stat("dir/dst.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.Stat() error = %v", err)
+	}
+	if info.ETag != "098f6bcd4621d373cade4e832627b4f6" {
+		t.Errorf("LocalStorage.Stat() ETag = %v, want the ETag of the copied content", info.ETag)
+	}
+}
+
+func TestLocalStorage_Move(t *testing.T) {
+	type args struct {
+		src string
+		dst string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *gostorage.ObjectInfo
+		wantErr bool
+	}{
+		{
+			name: "move with attributes",
+			args: args{
+				src: "src.txt",
+				dst: "dst.txt",
+			},
+			want: &gostorage.ObjectInfo{
+				Key:         "dst.txt",
+				Size:        4,
+				ContentType: "application/x-test",
+				ETag:        "098f6bcd4621d373cade4e832627b4f6",
+				Metadata:    map[string]string{"owner": "tester"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "source not found",
+			args: args{
+				src: "missing.txt",
+				dst: "dst.txt",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			err = d.WriteWithOptions("src.txt", strings.NewReader("test"), gostorage.WriteOptions{
+				ContentType: "application/x-test",
+				Metadata:    map[string]string{"owner": "tester"},
+			})
+			if err != nil {
+				t.Errorf("LocalStorage.WriteWithOptions() error = %v", err)
+				return
+			}
+
+			err = d.Move(tt.args.src, tt.args.dst)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LocalStorage.Move() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, gostorage.ErrNotExist) {
+					t.Errorf("LocalStorage.Move() error = %v, want %v", err, gostorage.ErrNotExist)
+				}
+				return
+			}
+			got, err := d.Stat(tt.args.dst)
+			if err != nil {
+				t.Errorf("LocalStorage.Stat() error = %v", err)
+				return
+			}
+			got.LastModified = time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LocalStorage.Stat() = %+v, want %+v", got, tt.want)
+			}
+			if _, err := os.Stat("/tmp/test/src.txt"); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("LocalStorage.Move() source still exists: %v", err)
+			}
+			if _, err := os.Stat(d.metadataPath("src.txt")); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("LocalStorage.Move() attributes of the source still exist: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_DeletePrefix_pruneDirs(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     string
+		wantDirs   []string
+		wantNoDirs []string
+	}{
+		{
+			name:       "directory",
+			prefix:     "tenant-42/",
+			wantDirs:   []string{"tenant-43"},
+			wantNoDirs: []string{"tenant-42", "tenant-42/a", "tenant-42/a/b"},
+		},
+		{
+			name:       "subdirectory",
+			prefix:     "tenant-42/a/",
+			wantDirs:   []string{"tenant-42", "tenant-43"},
+			wantNoDirs: []string{"tenant-42/a", "tenant-42/a/b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"tenant-42/test.txt", "tenant-42/a/b/test.txt", "tenant-43/test.txt"} {
+				err := os.MkdirAll(path.Dir(path.Join("/tmp/test", key)), 0755)
+				if err != nil {
+					t.Errorf("error creating directory: %v", err)
+				}
+				err = ioutil.WriteFile(path.Join("/tmp/test", key), []byte("test"), 0644)
+				if err != nil {
+					t.Errorf("error creating file: %v", err)
+				}
+			}
+			defer os.RemoveAll("/tmp/test")
+
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			_, err := d.DeletePrefix(tt.prefix, gostorage.DeletePrefixOptions{})
+			if err != nil {
+				t.Errorf("LocalStorage.DeletePrefix() error = %v", err)
+				return
+			}
+			for _, dir := range tt.wantDirs {
+				if _, err := os.Stat(path.Join("/tmp/test", dir)); err != nil {
+					t.Errorf("LocalStorage.DeletePrefix() removed directory %s: %v", dir, err)
+				}
+			}
+			for _, dir := range tt.wantNoDirs {
+				if _, err := os.Stat(path.Join("/tmp/test", dir)); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("LocalStorage.DeletePrefix() kept directory %s: %v", dir, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalStorage_pruneDirs_concurrentWrites(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	d := LocalStorage{
+		Path: "/tmp/test",
+	}
+	// every delete prunes the directories the other writers create their files in
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				err := d.Write(key, strings.NewReader("test"))
+				if err == nil {
+					err = d.Move(key, key+".moved")
+				}
+				if err == nil {
+					err = d.Delete(key + ".moved")
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}("tenant/a/" + strconv.Itoa(i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("LocalStorage error = %v", err)
+	}
+}
+
+func TestLocalStorage_SignURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		signMethod string
+		key        string
+		expiry     time.Duration
+		method     string
+		body       string
+		tamper     bool
+		wantStatus int
+		wantBody   string
+		wantFile   string
+	}{
+		{
+			name:       "get",
+			signMethod: http.MethodGet,
+			key:        "dir/test file.txt",
+			expiry:     time.Minute,
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantBody:   "test",
+		},
+		{
+			name:       "head signed for get",
+			signMethod: http.MethodGet,
+			key:        "dir/test file.txt",
+			expiry:     time.Minute,
+			method:     http.MethodHead,
+			wantStatus: http.StatusOK,
+			wantBody:   "",
+		},
+		{
+			name:       "put",
+			signMethod: http.MethodPut,
+			key:        "dir/new.txt",
+			expiry:     time.Minute,
+			method:     http.MethodPut,
+			body:       "uploaded",
+			wantStatus: http.StatusOK,
+			wantFile:   "uploaded",
+		},
+		{
+			name:       "put signed for get",
+			signMethod: http.MethodGet,
+			key:        "dir/new.txt",
+			expiry:     time.Minute,
+			method:     http.MethodPut,
+			body:       "uploaded",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "expired",
+			signMethod: http.MethodGet,
+			key:        "dir/test file.txt",
+			expiry:     -time.Minute,
+			method:     http.MethodGet,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "tampered signature",
+			signMethod: http.MethodGet,
+			key:        "dir/test file.txt",
+			expiry:     time.Minute,
+			method:     http.MethodGet,
+			tamper:     true,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test/dir", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			err = ioutil.WriteFile("/tmp/test/dir/test file.txt", []byte("test"), 0644)
+			if err != nil {
+				t.Errorf("error creating file: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+
+			var handler http.Handler
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handler.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+			d := LocalStorage{
+				Path:       "/tmp/test",
+				SigningKey: []byte("secret"),
+				BaseURL:    server.URL + "/storage",
+			}
+			handler = d.SignedURLHandler()
+
+			signedURL, err := d.SignURL(tt.signMethod, tt.key, tt.expiry)
+			if err != nil {
+				t.Errorf("LocalStorage.SignURL() error = %v", err)
+				return
+			}
+			if tt.tamper {
+				signedURL = strings.Replace(signedURL, "signature=", "signature=00", 1)
+			}
+			req, err := http.NewRequest(tt.method, signedURL, strings.NewReader(tt.body))
+			if err != nil {
+				t.Errorf("http.NewRequest() error = %v", err)
+				return
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+				return
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("status = %v, want %v", res.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Errorf("ReadAll() error = %v", err)
+				return
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if tt.wantFile != "" {
+				got, err := ioutil.ReadFile(path.Join("/tmp/test", tt.key))
+				if err != nil {
+					t.Errorf("ReadFile() error = %v", err)
+					return
+				}
+				if string(got) != tt.wantFile {
+					t.Errorf("file = %q, want %q", got, tt.wantFile)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalStorage_SignURL_notSupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		d      LocalStorage
+		method string
+	}{
+		{
+			name:   "missing signing key",
+			d:      LocalStorage{Path: "/tmp/test", BaseURL: "http://localhost/storage"},
+			method: http.MethodGet,
+		},
+		{
+			name:   "missing base url",
+			d:      LocalStorage{Path: "/tmp/test", SigningKey: []byte("secret")},
+			method: http.MethodGet,
+		},
+		{
+			name:   "unsupported method",
+			d:      LocalStorage{Path: "/tmp/test", SigningKey: []byte("secret"), BaseURL: "http://localhost/storage"},
+			method: http.MethodDelete,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.d.SignURL(tt.method, "test.txt", time.Minute)
+			if !errors.Is(err, gostorage.ErrNotSupported) {
+				t.Errorf("LocalStorage.SignURL() error = %v, want %v", err, gostorage.ErrNotSupported)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_Versioning_nullVersion(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+	err = ioutil.WriteFile("/tmp/test/test.txt", []byte("unversioned"), 0644)
+	if err != nil {
+		t.Errorf("error creating file: %v", err)
+	}
+
+	d := LocalStorage{
+		Path:       "/tmp/test",
+		Versioning: true,
+	}
+	versions, err := d.ListVersions("test.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.ListVersions() error = %v", err)
+	}
+	if len(versions) != 1 || versions[0].VersionID != gostorage.NullVersionID || !versions[0].IsLatest {
+		t.Errorf("LocalStorage.ListVersions() = %+v, want the latest null version", versions)
+	}
+
+	err = d.Write("test.txt", strings.NewReader("versioned"))
+	if err != nil {
+		t.Fatalf("LocalStorage.Write() error = %v", err)
+	}
+	versions, err = d.ListVersions("test.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.ListVersions() error = %v", err)
+	}
+	if len(versions) != 2 || versions[1].VersionID != gostorage.NullVersionID {
+		t.Errorf("LocalStorage.ListVersions() = %+v, want the null version last", versions)
+	}
+	rc, err := d.ReadVersion("test.txt", gostorage.NullVersionID)
+	if err != nil {
+		t.Fatalf("LocalStorage.ReadVersion() error = %v", err)
+	}
+	defer rc.Close()
+	bts, _ := ioutil.ReadAll(rc)
+	if string(bts) != "unversioned" {
+		t.Errorf("LocalStorage.ReadVersion() = %q, want %q", bts, "unversioned")
+	}
+
+	for _, id := range []string{"../test.txt", "", "missing"} {
+		_, err = d.ReadVersion("test.txt", id)
+		if !errors.Is(err, gostorage.ErrNotExist) {
+			t.Errorf("LocalStorage.ReadVersion(%q) error = %v, want %v", id, err, gostorage.ErrNotExist)
+		}
+	}
+}
+
+func TestLocalStorage_Versioning_nestedKeys(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+	// the version IDs of docs must not collide with the key docs/null
+	err = ioutil.WriteFile("/tmp/test/docs", []byte("unversioned"), 0644)
+	if err != nil {
+		t.Errorf("error creating file: %v", err)
+	}
+
+	d := LocalStorage{
+		Path:       "/tmp/test",
+		Versioning: true,
+	}
+	err = d.Write("docs", strings.NewReader("versioned"))
+	if err != nil {
+		t.Fatalf("LocalStorage.Write() error = %v", err)
+	}
+	err = d.Delete("docs")
+	if err != nil {
+		t.Fatalf("LocalStorage.Delete() error = %v", err)
+	}
+	for _, value := range []string{"first", "second"} {
+		err = d.Write("docs/null", strings.NewReader(value))
+		if err != nil {
+			t.Fatalf("LocalStorage.Write() error = %v", err)
+		}
+	}
+
+	for key, want := range map[string]int{"docs": 3, "docs/null": 2} {
+		versions, err := d.ListVersions(key)
+		if err != nil {
+			t.Fatalf("LocalStorage.ListVersions() error = %v", err)
+		}
+		if len(versions) != want {
+			t.Errorf("LocalStorage.ListVersions(%q) = %+v, want %d versions", key, versions, want)
+		}
+	}
+}
+
+func TestLocalStorage_Versioning_concurrentReads(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	d := LocalStorage{
+		Path:       "/tmp/test",
+		Versioning: true,
+	}
+	err = d.Write("test.txt", strings.NewReader("0"))
+	if err != nil {
+		t.Fatalf("LocalStorage.Write() error = %v", err)
+	}
+
+	const readers = 4
+	done := make(chan struct{})
+	errs := make(chan error, readers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				_, err := d.Read("test.txt")
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 300; i++ {
+		err = d.Write("test.txt", strings.NewReader(strconv.Itoa(i)))
+		if err != nil {
+			t.Errorf("LocalStorage.Write() error = %v", err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		t.Errorf("LocalStorage.Read() error = %v while the file is replaced", err)
+	}
+}
+
+func TestLocalStorage_Versioning_invalidVersionID(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	d := LocalStorage{
+		Path:       "/tmp/test",
+		Versioning: true,
+	}
+	for _, content := range []string{"first", "second"} {
+		err = d.WriteWithOptions("test.txt", strings.NewReader(content), gostorage.WriteOptions{ContentType: "text/plain"})
+		if err != nil {
+			t.Fatalf("LocalStorage.Write() error = %v", err)
+		}
+	}
+	err = d.Delete("test.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.Delete() error = %v", err)
+	}
+	versions, err := d.ListVersions("test.txt")
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("LocalStorage.ListVersions() = %+v, %v, want three versions", versions, err)
+	}
+
+	for _, id := range []string{
+		versions[1].VersionID + versionMetadataSuffix,
+		versions[0].VersionID + deleteMarkerSuffix,
+		"../" + versions[1].VersionID,
+		strings.ToUpper(versions[1].VersionID),
+		"",
+	} {
+		_, err := d.ReadVersion("test.txt", id)
+		if !errors.Is(err, gostorage.ErrNotExist) {
+			t.Errorf("LocalStorage.ReadVersion(%q) error = %v, want %v", id, err, gostorage.ErrNotExist)
+		}
+		err = d.DeleteVersion("test.txt", id)
+		if !errors.Is(err, gostorage.ErrNotExist) {
+			t.Errorf("LocalStorage.DeleteVersion(%q) error = %v, want %v", id, err, gostorage.ErrNotExist)
+		}
+	}
+	for _, name := range []string{
+		d.versionPath("test.txt", versions[1].VersionID) + versionMetadataSuffix,
+		d.versionPath("test.txt", versions[0].VersionID) + deleteMarkerSuffix,
+	} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("LocalStorage.DeleteVersion() removed %s: %v", name, err)
+		}
+	}
+}
+
+func TestLocalStorage_Versioning_Move(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	d := LocalStorage{
+		Path:       "/tmp/test",
+		Versioning: true,
+	}
+	for key, content := range map[string]string{"src.txt": "src", "dst.txt": "dst"} {
+		err = d.Write(key, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("LocalStorage.Write() error = %v", err)
+		}
+	}
+	err = d.Move("src.txt", "dst.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.Move() error = %v", err)
+	}
+
+	// the replaced content of dst is kept and src is marked as deleted
+	versions, err := d.ListVersions("dst.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.ListVersions() error = %v", err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("LocalStorage.ListVersions(dst.txt) = %+v, want 2 versions", versions)
+	}
+	versions, err = d.ListVersions("src.txt")
+	if err != nil {
+		t.Fatalf("LocalStorage.ListVersions() error = %v", err)
+	}
+	if len(versions) != 2 || !versions[0].DeleteMarker {
+		t.Errorf("LocalStorage.ListVersions(src.txt) = %+v, want a delete marker and a version", versions)
+	}
+}
+
+func TestLocalStorage_CompleteUpload_invalidParts(t *testing.T) {
+	tests := []struct {
+		name    string
+		parts   func(parts []gostorage.Part) []gostorage.Part
+		wantErr error
+	}{
+		{
+			name: "etag mismatch",
+			parts: func(parts []gostorage.Part) []gostorage.Part {
+				parts[0].ETag = "mismatch"
+				return parts
+			},
+		},
+		{
+			name: "descending order",
+			parts: func(parts []gostorage.Part) []gostorage.Part {
+				return []gostorage.Part{parts[1], parts[0]}
+			},
+		},
+		{
+			name: "missing part",
+			parts: func(parts []gostorage.Part) []gostorage.Part {
+				return append(parts, gostorage.Part{Number: 3})
+			},
+			wantErr: gostorage.ErrNotExist,
+		},
+		{
+			name: "no parts",
+			parts: func(parts []gostorage.Part) []gostorage.Part {
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			upload, err := d.InitiateUpload("test.txt", gostorage.WriteOptions{})
+			if err != nil {
+				t.Fatalf("LocalStorage.InitiateUpload() error = %v", err)
+			}
+			parts := []gostorage.Part{}
+			for number, content := range []string{"first", "second"} {
+				part, err := d.UploadPart(upload, number+1, strings.NewReader(content))
+				if err != nil {
+					t.Fatalf("LocalStorage.UploadPart() error = %v", err)
+				}
+				parts = append(parts, *part)
+			}
+
+			err = d.CompleteUpload(upload, tt.parts(append([]gostorage.Part{}, parts...)))
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("LocalStorage.CompleteUpload() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := os.Stat("/tmp/test/test.txt"); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("LocalStorage.CompleteUpload() created the file: %v", err)
+			}
+			// the upload can still be completed with the valid parts
+			err = d.CompleteUpload(upload, parts)
+			if err != nil {
+				t.Errorf("LocalStorage.CompleteUpload() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_ListParts_storedETag(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(name string) error
+		wantETag string
+	}{
+		{
+			name:     "unmodified part",
+			modify:   func(name string) error { return nil },
+			wantETag: "098f6bcd4621d373cade4e832627b4f6",
+		},
+		{
+			// the stored ETag is used, so the part is not read
+			name: "content replaced with the same size and modification time",
+			modify: func(name string) error {
+				fInfo, err := os.Stat(name)
+				if err != nil {
+					return err
+				}
+				err = ioutil.WriteFile(name, []byte("abcd"), 0644)
+				if err != nil {
+					return err
+				}
+				return os.Chtimes(name, fInfo.ModTime(), fInfo.ModTime())
+			},
+			wantETag: "098f6bcd4621d373cade4e832627b4f6",
+		},
+		{
+			name: "part modified externally",
+			modify: func(name string) error {
+				return ioutil.WriteFile(name, []byte("modified"), 0644)
+			},
+			wantETag: "9ae73c65f418e6f79ceb4f0e4a4b98d5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.MkdirAll("/tmp/test", 0755)
+			if err != nil {
+				t.Errorf("error creating directory: %v", err)
+			}
+			defer os.RemoveAll("/tmp/test")
+			d := LocalStorage{
+				Path: "/tmp/test",
+			}
+			upload, err := d.InitiateUpload("test.txt", gostorage.WriteOptions{})
+			if err != nil {
+				t.Fatalf("LocalStorage.InitiateUpload() error = %v", err)
+			}
+			_, err = d.UploadPart(upload, 1, strings.NewReader("test"))
+			if err != nil {
+				t.Fatalf("LocalStorage.UploadPart() error = %v", err)
+			}
+			dir, _ := d.uploadDir(upload)
+			err = tt.modify(partPath(dir, 1))
+			if err != nil {
+				t.Errorf("error modifying part: %v", err)
+				return
+			}
+			got, err := d.ListParts(upload)
+			if err != nil {
+				t.Errorf("LocalStorage.ListParts() error = %v", err)
+				return
+			}
+			if len(got) != 1 || got[0].ETag != tt.wantETag {
+				t.Errorf("LocalStorage.ListParts() = %+v, want one part with ETag %v", got, tt.wantETag)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_nestedKeys(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+
+	dirPermissions := 0700
+	d := LocalStorage{
+		Path:           "/tmp/test",
+		DirPermissions: &dirPermissions,
+	}
+	for _, key := range []string{"invoices/2024/01/a.pdf", "invoices/2024/02/b.pdf"} {
+		err = d.Write(key, strings.NewReader(key))
+		if err != nil {
+			t.Fatalf("LocalStorage.Write() error = %v", err)
+		}
+	}
+	fInfo, err := os.Stat("/tmp/test/invoices/2024/01")
+	if err != nil {
+		t.Fatalf("LocalStorage.Write() did not create the directory: %v", err)
+	}
+	if fInfo.Mode().Perm() != 0700 {
+		t.Errorf("LocalStorage.Write() created the directory with %v, want %v", fInfo.Mode().Perm(), fs.FileMode(0700))
+	}
+
+	err = d.Move("invoices/2024/02/b.pdf", "archive/b.pdf")
+	if err != nil {
+		t.Fatalf("LocalStorage.Move() error = %v", err)
+	}
+	keys, err := d.List()
+	want := []string{"archive/b.pdf", "invoices/2024/01/a.pdf"}
+	if err != nil || !reflect.DeepEqual(keys, want) {
+		t.Errorf("LocalStorage.List() = %v, %v, want %v", keys, err, want)
+	}
+
+	// the empty parent directories are removed, but never the root directory
+	for _, key := range want {
+		err = d.Delete(key)
+		if err != nil {
+			t.Fatalf("LocalStorage.Delete() error = %v", err)
+		}
+	}
+	entries, err := os.ReadDir("/tmp/test")
+	if err != nil {
+		t.Fatalf("error reading directory: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != internalDir {
+			t.Errorf("LocalStorage.Delete() left %s", entry.Name())
+		}
+	}
+}
+
+func TestLocalStorage_parentIsFile(t *testing.T) {
+	err := os.MkdirAll("/tmp/test", 0755)
+	if err != nil {
+		t.Errorf("error creating directory: %v", err)
+	}
+	defer os.RemoveAll("/tmp/test")
+	err = ioutil.WriteFile("/tmp/test/file.txt", []byte("test"), 0644)
+	if err != nil {
+		t.Errorf("error creating file: %v", err)
+	}
+
+	d := LocalStorage{
+		Path: "/tmp/test",
+	}
+	tests := []struct {
+		name string
+		op   func(key string) error
+	}{
+		{
+			name: "read",
+			op: func(key string) error {
+				_, err := d.Read(key)
+				return err
+			},
+		},
+		{
+			name: "stat",
+			op: func(key string) error {
+				_, err := d.Stat(key)
+				return err
+			},
+		},
+		{
+			name: "delete",
+			op:   d.Delete,
+		},
+		{
+			name: "move",
+			op: func(key string) error {
+				return d.Move(key, "dst.txt")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op("file.txt/x")
+			if !errors.Is(err, gostorage.ErrNotExist) {
+				t.Errorf("error = %v, want %v", err, gostorage.ErrNotExist)
+			}
+		})
+	}
+
+	exists, err := d.Exists("file.txt/x")
+	if exists || err != nil {
+		t.Errorf("LocalStorage.Exists() = %v, %v, want false", exists, err)
+	}
+	errs := d.DeleteMany([]string{"file.txt/x"})
+	if len(errs) != 0 {
+		t.Errorf("LocalStorage.DeleteMany() = %v, want no errors", errs)
+	}
+}
+
+func TestLocalStorage_symlinkEscape(t *testing.T) {
+	for _, dir := range []string{"/tmp/test/dir", "/tmp/test-outside"} {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			t.Errorf("error creating directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		err = ioutil.WriteFile(path.Join(dir, "test.txt"), []byte("test"), 0644)
+		if err != nil {
+			t.Errorf("error creating file: %v", err)
+		}
+	}
+	defer os.RemoveAll("/tmp/test")
+	defer os.Remove("/tmp/test-root")
+	links := map[string]string{
+		"/tmp/test/outside":      "/tmp/test-outside",
+		"/tmp/test/outside.txt":  "/tmp/test-outside/test.txt",
+		"/tmp/test/relative":     "../test-outside",
+		"/tmp/test/inside":       "/tmp/test/dir",
+		"/tmp/test/inside.txt":   "dir/test.txt",
+		"/tmp/test-root":         "/tmp/test",
+		"/tmp/test/dir/loop.txt": "../dir/test.txt",
+	}
+	for link, target := range links {
+		err := os.Symlink(target, link)
+		if err != nil {
+			t.Fatalf("error creating symlink: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		key     string
+		wantErr error
+	}{
+		{
+			name:    "directory outside",
+			path:    "/tmp/test",
+			key:     "outside/test.txt",
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name:    "file outside",
+			path:    "/tmp/test",
+			key:     "outside.txt",
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name:    "relative link outside",
+			path:    "/tmp/test",
+			key:     "relative/test.txt",
+			wantErr: gostorage.ErrInvalidKey,
+		},
+		{
+			name: "directory inside",
+			path: "/tmp/test",
+			key:  "inside/test.txt",
+		},
+		{
+			name: "file inside",
+			path: "/tmp/test",
+			key:  "inside.txt",
+		},
+		{
+			name: "link within directory",
+			path: "/tmp/test",
+			key:  "dir/loop.txt",
+		},
+		{
+			name: "linked root directory",
+			path: "/tmp/test-root",
+			key:  "dir/test.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := LocalStorage{
+				Path: tt.path,
+			}
+			_, err := d.Read(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LocalStorage.Read() error = %v, want %v", err, tt.wantErr)
+			}
+			err = d.Write(tt.key, strings.NewReader("new"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LocalStorage.Write() error = %v, want %v", err, tt.wantErr)
+			}
+			bts, err := ioutil.ReadFile("/tmp/test-outside/test.txt")
+			if err != nil || string(bts) != "test" {
+				t.Errorf("LocalStorage.Write() wrote outside of the root directory: %q, %v", bts, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package drivers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+func init() {
+	gostorage.Register("s3", openS3)
+	gostorage.Register("file", openLocalStorage)
+}
+
+// openS3 creates the S3 driver for an URL like
+// "s3://bucket/prefix?region=eu-central-1&endpoint=http://localhost:4566&disable_ssl=true".
+// The credentials are taken from the user info of the URL, e.g. "s3://key:secret@bucket".
+// Without user info, the default credential chain of the aws sdk is used.
+func openS3(u *url.URL) (gostorage.Driver, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("s3: missing bucket in url %q", u.Redacted())
+	}
+	config := S3Config{
+		Bucket:     u.Host,
+		PathPrefix: strings.TrimPrefix(u.Path, "/"),
+	}
+	if u.User != nil {
+		config.AccessKeyID = u.User.Username()
+		config.SecretAccessKey, _ = u.User.Password()
+	}
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch key {
+		case "region":
+			config.Region = value
+		case "endpoint":
+			config.Endpoint = value
+		case "disable_ssl":
+			disableSSL, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("s3: invalid disable_ssl %q: %w", value, err)
+			}
+			config.DisableSSL = disableSSL
+		default:
+			return nil, fmt.Errorf("s3: unknown parameter %q", key)
+		}
+	}
+	return NewS3FromConfig(config)
+}
+
+// openLocalStorage creates the LocalStorage driver for an URL like "file:///var/data"
+// or "file:data" for a path relative to the working directory.
+// The file permissions can be set by the parameter "permissions", e.g. "permissions=0600",
+// and the permissions of created directories by the parameter "dir_permissions".
+func openLocalStorage(u *url.URL) (gostorage.Driver, error) {
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("local-storage: unsupported host %q in url %q", u.Host, u.String())
+	}
+	path := u.Path
+	if u.Opaque != "" {
+		path = u.Opaque
+	}
+	if path == "" {
+		return nil, fmt.Errorf("local-storage: missing path in url %q", u.String())
+	}
+	d := NewLocalStorage(path)
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch key {
+		case "permissions":
+			permissions, err := strconv.ParseUint(value, 8, 32)
+			if err != nil {
+				return nil, fmt.Errorf("local-storage: invalid permissions %q: %w", value, err)
+			}
+			perm := int(permissions)
+			d.Permissions = &perm
+		case "dir_permissions":
+			permissions, err := strconv.ParseUint(value, 8, 32)
+			if err != nil {
+				return nil, fmt.Errorf("local-storage: invalid dir_permissions %q: %w", value, err)
+			}
+			perm := int(permissions)
+			d.DirPermissions = &perm
+		default:
+			return nil, fmt.Errorf("local-storage: unknown parameter %q", key)
+		}
+	}
+	return d, nil
+}
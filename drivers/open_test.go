@@ -0,0 +1,174 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+func TestOpen_s3(t *testing.T) {
+	tests := []struct {
+		name            string
+		rawURL          string
+		wantBucket      string
+		wantPathPrefix  string
+		wantRegion      string
+		wantEndpoint    string
+		wantAccessKeyID string
+		wantErr         bool
+	}{
+		{
+			name:           "bucket",
+			rawURL:         "s3://bucket?region=eu-central-1",
+			wantBucket:     "bucket",
+			wantPathPrefix: "",
+			wantRegion:     "eu-central-1",
+			wantErr:        false,
+		},
+		{
+			name:            "all parameters",
+			rawURL:          "s3://key:secret@bucket/tenant-42/?region=eu-central-1&endpoint=http://localhost:4566&disable_ssl=true",
+			wantBucket:      "bucket",
+			wantPathPrefix:  "tenant-42/",
+			wantRegion:      "eu-central-1",
+			wantEndpoint:    "http://localhost:4566",
+			wantAccessKeyID: "key",
+			wantErr:         false,
+		},
+		{
+			name:    "missing bucket",
+			rawURL:  "s3:///prefix",
+			wantErr: true,
+		},
+		{
+			name:    "unknown parameter",
+			rawURL:  "s3://bucket?regoin=eu-central-1",
+			wantErr: true,
+		},
+		{
+			name:    "invalid disable_ssl",
+			rawURL:  "s3://bucket?disable_ssl=maybe",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := gostorage.Open(tt.rawURL)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Open() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, ok := d.(*S3)
+			if !ok {
+				t.Errorf("Open() = %T, want %T", d, got)
+				return
+			}
+			if got.Bucket != tt.wantBucket || got.PathPrefix != tt.wantPathPrefix {
+				t.Errorf("Open() = %q %q, want %q %q", got.Bucket, got.PathPrefix, tt.wantBucket, tt.wantPathPrefix)
+			}
+			if region := aws.StringValue(got.session.Config.Region); region != tt.wantRegion {
+				t.Errorf("Open() region = %q, want %q", region, tt.wantRegion)
+			}
+			if endpoint := aws.StringValue(got.session.Config.Endpoint); endpoint != tt.wantEndpoint {
+				t.Errorf("Open() endpoint = %q, want %q", endpoint, tt.wantEndpoint)
+			}
+			if tt.wantAccessKeyID != "" {
+				creds, err := got.session.Config.Credentials.Get()
+				if err != nil {
+					t.Errorf("Credentials.Get() error = %v", err)
+					return
+				}
+				if creds.AccessKeyID != tt.wantAccessKeyID {
+					t.Errorf("Open() access key id = %q, want %q", creds.AccessKeyID, tt.wantAccessKeyID)
+				}
+			}
+		})
+	}
+}
+
+func TestOpen_localStorage(t *testing.T) {
+	permissions := 0600
+	dirPermissions := 0700
+	tests := []struct {
+		name    string
+		rawURL  string
+		want    *LocalStorage
+		wantErr bool
+	}{
+		{
+			name:    "absolute path",
+			rawURL:  "file:///var/data",
+			want:    &LocalStorage{Path: "/var/data"},
+			wantErr: false,
+		},
+		{
+			name:    "localhost",
+			rawURL:  "file://localhost/var/data",
+			want:    &LocalStorage{Path: "/var/data"},
+			wantErr: false,
+		},
+		{
+			name:    "relative path",
+			rawURL:  "file:data",
+			want:    &LocalStorage{Path: "data"},
+			wantErr: false,
+		},
+		{
+			name:    "permissions",
+			rawURL:  "file:///var/data?permissions=0600",
+			want:    &LocalStorage{Path: "/var/data", Permissions: &permissions},
+			wantErr: false,
+		},
+		{
+			name:    "dir permissions",
+			rawURL:  "file:///var/data?dir_permissions=0700",
+			want:    &LocalStorage{Path: "/var/data", DirPermissions: &dirPermissions},
+			wantErr: false,
+		},
+		{
+			name:    "remote host",
+			rawURL:  "file://remote/var/data",
+			wantErr: true,
+		},
+		{
+			name:    "missing path",
+			rawURL:  "file://",
+			wantErr: true,
+		},
+		{
+			name:    "invalid permissions",
+			rawURL:  "file:///var/data?permissions=rw",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := gostorage.Open(tt.rawURL)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Open() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, ok := d.(*LocalStorage)
+			if !ok {
+				t.Errorf("Open() = %T, want %T", d, got)
+				return
+			}
+			if got.Path != tt.want.Path {
+				t.Errorf("Open() Path = %v, want %v", got.Path, tt.want.Path)
+			}
+			if got.filePermissions() != tt.want.filePermissions() {
+				t.Errorf("Open() permissions = %v, want %v", got.filePermissions(), tt.want.filePermissions())
+			}
+			if got.dirPermissions() != tt.want.dirPermissions() {
+				t.Errorf("Open() dir permissions = %v, want %v", got.dirPermissions(), tt.want.dirPermissions())
+			}
+		})
+	}
+}
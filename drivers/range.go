@@ -0,0 +1,51 @@
+package drivers
+
+import (
+	"fmt"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// validateRange validates the byte range defined by offset and length
+// as documented by gostorage.RangeReader.
+func validateRange(offset, length int64) error {
+	if length == 0 || (offset < 0 && length > 0) {
+		return fmt.Errorf("%w: offset %d, length %d", gostorage.ErrInvalidRange, offset, length)
+	}
+	return nil
+}
+
+// httpRange returns the value of the HTTP Range header for the byte range.
+// The range must have been validated by validateRange.
+func httpRange(offset, length int64) string {
+	switch {
+	case offset < 0:
+		return fmt.Sprintf("bytes=%d", offset)
+	case length < 0:
+		return fmt.Sprintf("bytes=%d-", offset)
+	default:
+		return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+}
+
+// resolveRange returns the start and the number of bytes of the byte range
+// within content of the given size. The range must have been validated by validateRange.
+func resolveRange(offset, length, size int64) (int64, int64, error) {
+	if offset < 0 {
+		start := size + offset
+		if start < 0 {
+			start = 0
+		}
+		if size == 0 {
+			return 0, 0, fmt.Errorf("%w: suffix of %d bytes of empty content", gostorage.ErrInvalidRange, -offset)
+		}
+		return start, size - start, nil
+	}
+	if offset >= size {
+		return 0, 0, fmt.Errorf("%w: offset %d beyond size %d", gostorage.ErrInvalidRange, offset, size)
+	}
+	if length < 0 || offset+length > size {
+		return offset, size - offset, nil
+	}
+	return offset, length, nil
+}
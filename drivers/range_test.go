@@ -0,0 +1,173 @@
+package drivers
+
+import (
+	"errors"
+	"testing"
+
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+func Test_validateRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  int64
+		length  int64
+		wantErr bool
+	}{
+		{
+			name:    "range",
+			offset:  2,
+			length:  3,
+			wantErr: false,
+		},
+		{
+			name:    "until end",
+			offset:  2,
+			length:  -1,
+			wantErr: false,
+		},
+		{
+			name:    "suffix",
+			offset:  -3,
+			length:  -1,
+			wantErr: false,
+		},
+		{
+			name:    "zero length",
+			offset:  0,
+			length:  0,
+			wantErr: true,
+		},
+		{
+			name:    "negative offset with length",
+			offset:  -3,
+			length:  2,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRange(tt.offset, tt.length)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, gostorage.ErrInvalidRange) {
+				t.Errorf("validateRange() error = %v, want %v", err, gostorage.ErrInvalidRange)
+			}
+		})
+	}
+}
+
+func Test_httpRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int64
+		length int64
+		want   string
+	}{
+		{
+			name:   "range",
+			offset: 2,
+			length: 3,
+			want:   "bytes=2-4",
+		},
+		{
+			name:   "until end",
+			offset: 2,
+			length: -1,
+			want:   "bytes=2-",
+		},
+		{
+			name:   "suffix",
+			offset: -3,
+			length: -1,
+			want:   "bytes=-3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := httpRange(tt.offset, tt.length); got != tt.want {
+				t.Errorf("httpRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolveRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		offset    int64
+		length    int64
+		size      int64
+		wantStart int64
+		wantN     int64
+		wantErr   bool
+	}{
+		{
+			name:      "range",
+			offset:    2,
+			length:    3,
+			size:      10,
+			wantStart: 2,
+			wantN:     3,
+		},
+		{
+			name:      "range beyond end",
+			offset:    8,
+			length:    5,
+			size:      10,
+			wantStart: 8,
+			wantN:     2,
+		},
+		{
+			name:      "until end",
+			offset:    2,
+			length:    -1,
+			size:      10,
+			wantStart: 2,
+			wantN:     8,
+		},
+		{
+			name:      "suffix",
+			offset:    -3,
+			length:    -1,
+			size:      10,
+			wantStart: 7,
+			wantN:     3,
+		},
+		{
+			name:      "suffix longer than content",
+			offset:    -20,
+			length:    -1,
+			size:      10,
+			wantStart: 0,
+			wantN:     10,
+		},
+		{
+			name:    "suffix of empty content",
+			offset:  -3,
+			length:  -1,
+			size:    0,
+			wantErr: true,
+		},
+		{
+			name:    "offset at end",
+			offset:  10,
+			length:  1,
+			size:    10,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, n, err := resolveRange(tt.offset, tt.length, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveRange() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if start != tt.wantStart || n != tt.wantN {
+				t.Errorf("resolveRange() = (%v, %v), want (%v, %v)", start, n, tt.wantStart, tt.wantN)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+var (
+	// maxCopyObjectSize is the largest object that can be copied by a single CopyObject request.
+	// Larger objects are copied in parts by UploadPartCopy.
+	maxCopyObjectSize int64 = 5 << 30
+	// copyPartSize is the size of the parts larger objects are copied in.
+	copyPartSize int64 = 512 << 20
+)
+
+// maxParts is the maximum number of parts of a multipart upload.
+const maxParts = 10000
+
+// Copy copies the object src including its attributes to dst within the bucket.
+func (s3def S3) Copy(src, dst string) error {
+	return s3def.CopyContext(context.Background(), src, dst)
+}
+
+// CopyContext copies the object src including its attributes to dst within the bucket.
+// The object is copied on the server side, by CopyObject or by UploadPartCopy
+// if the object is larger than 5 GiB.
+func (s3def S3) CopyContext(ctx context.Context, src, dst string) error {
+	ho, err := s3def.conn.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: &s3def.Bucket,
+		Key:    aws.String(s3def.objectKey(src)),
+	})
+	if err != nil {
+		return s3Error("copy", src, err)
+	}
+	if src == dst {
+		return nil
+	}
+	copySource := url.PathEscape(s3def.Bucket + "/" + s3def.objectKey(src))
+
+	if aws.Int64Value(ho.ContentLength) <= maxCopyObjectSize {
+		_, err = s3def.conn.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+			Bucket:     &s3def.Bucket,
+			Key:        aws.String(s3def.objectKey(dst)),
+			CopySource: &copySource,
+		})
+		if err != nil {
+			return s3Error("copy", src, err)
+		}
+		return nil
+	}
+
+	err = s3def.copyParts(ctx, copySource, s3def.objectKey(dst), ho)
+	if err != nil {
+		return s3Error("copy", src, err)
+	}
+	return nil
+}
+
+// copyParts copies the object described by ho to dst in parts.
+// dst is the key of the object within the bucket, including the path prefix.
+func (s3def S3) copyParts(ctx context.Context, copySource, dst string, ho *s3.HeadObjectOutput) error {
+	mpu, err := s3def.conn.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:             &s3def.Bucket,
+		Key:                &dst,
+		ContentType:        ho.ContentType,
+		CacheControl:       ho.CacheControl,
+		ContentDisposition: ho.ContentDisposition,
+		ContentEncoding:    ho.ContentEncoding,
+		Metadata:           ho.Metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	size := aws.Int64Value(ho.ContentLength)
+	partSize := copyPartSize
+	if size/partSize >= maxParts {
+		partSize = size/(maxParts-1) + 1
+	}
+	var parts []*s3.CompletedPart
+	for offset, number := int64(0), int64(1); offset < size; offset, number = offset+partSize, number+1 {
+		end := offset + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		upc, err := s3def.conn.UploadPartCopyWithContext(ctx, &s3.UploadPartCopyInput{
+			Bucket:            &s3def.Bucket,
+			Key:               &dst,
+			UploadId:          mpu.UploadId,
+			PartNumber:        aws.Int64(number),
+			CopySource:        &copySource,
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", offset, end)),
+			CopySourceIfMatch: ho.ETag,
+		})
+		if err != nil {
+			s3def.abortUpload(dst, mpu.UploadId)
+			return err
+		}
+		parts = append(parts, &s3.CompletedPart{
+			ETag:       upc.CopyPartResult.ETag,
+			PartNumber: aws.Int64(number),
+		})
+	}
+
+	_, err = s3def.conn.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &s3def.Bucket,
+		Key:             &dst,
+		UploadId:        mpu.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		s3def.abortUpload(dst, mpu.UploadId)
+		return err
+	}
+	return nil
+}
+
+// abortUpload aborts the multipart upload of the object with the key within the bucket. It uses its own context, so that
+// the upload is aborted even if the context of the failed operation is done.
+func (s3def S3) abortUpload(key string, uploadID *string) {
+	_, _ = s3def.conn.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   &s3def.Bucket,
+		Key:      &key,
+		UploadId: uploadID,
+	})
+}
+
+// Move moves the object src including its attributes to dst within the bucket.
+func (s3def S3) Move(src, dst string) error {
+	return s3def.MoveContext(context.Background(), src, dst)
+}
+
+// MoveContext moves the object src including its attributes to dst within the bucket.
+// S3 can not rename objects, so src is copied on the server side and deleted afterwards.
+func (s3def S3) MoveContext(ctx context.Context, src, dst string) error {
+	err := s3def.CopyContext(ctx, src, dst)
+	if err != nil {
+		return err
+	}
+	if src == dst {
+		return nil
+	}
+	_, err = s3def.conn.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: &s3def.Bucket,
+		Key:    aws.String(s3def.objectKey(src)),
+	})
+	if err != nil {
+		return s3Error("move", src, err)
+	}
+	return nil
+}
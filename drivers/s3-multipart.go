@@ -0,0 +1,173 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
+)
+
+// InitiateUpload creates a multipart upload of the object.
+func (s3def S3) InitiateUpload(key string, opts gostorage.WriteOptions) (*gostorage.Upload, error) {
+	return s3def.InitiateUploadContext(context.Background(), key, opts)
+}
+
+// InitiateUploadContext creates a multipart upload of the object.
+// The attributes defined by opts are stored as object headers and metadata.
+func (s3def S3) InitiateUploadContext(ctx context.Context, key string, opts gostorage.WriteOptions) (*gostorage.Upload, error) {
+	if opts.IfNotExists || opts.IfMatch != "" {
+		return nil, s3Error("initiate upload", key, fmt.Errorf("%w: conditional multipart uploads", gostorage.ErrNotSupported))
+	}
+	res, err := s3def.conn.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:             &s3def.Bucket,
+		Key:                aws.String(s3def.objectKey(key)),
+		ContentType:        optionalString(opts.ContentType),
+		CacheControl:       optionalString(opts.CacheControl),
+		ContentDisposition: optionalString(opts.ContentDisposition),
+		ContentEncoding:    optionalString(opts.ContentEncoding),
+		Metadata:           objectMetadata(opts.Metadata),
+	})
+	if err != nil {
+		return nil, s3Error("initiate upload", key, err)
+	}
+	return &gostorage.Upload{
+		Key:      key,
+		UploadID: aws.StringValue(res.UploadId),
+	}, nil
+}
+
+// UploadPart uploads the part of the multipart upload.
+func (s3def S3) UploadPart(upload *gostorage.Upload, number int, value io.Reader) (*gostorage.Part, error) {
+	return s3def.UploadPartContext(context.Background(), upload, number, value)
+}
+
+// UploadPartContext uploads the part of the multipart upload.
+// The request must be signed including the size of the part, therefore values that
+// do not implement io.ReadSeeker are read into memory first.
+func (s3def S3) UploadPartContext(ctx context.Context, upload *gostorage.Upload, number int, value io.Reader) (*gostorage.Part, error) {
+	body, ok := value.(io.ReadSeeker)
+	if !ok {
+		bts, err := ioutil.ReadAll(utils.ContextReader(ctx, value))
+		if err != nil {
+			return nil, s3Error("upload part", upload.Key, err)
+		}
+		body = bytes.NewReader(bts)
+	}
+	size, err := remainingSize(body)
+	if err != nil {
+		return nil, s3Error("upload part", upload.Key, err)
+	}
+	res, err := s3def.conn.UploadPartWithContext(ctx, &s3.UploadPartInput{
+		Bucket:     &s3def.Bucket,
+		Key:        aws.String(s3def.objectKey(upload.Key)),
+		UploadId:   &upload.UploadID,
+		PartNumber: aws.Int64(int64(number)),
+		Body:       body,
+	})
+	if err != nil {
+		return nil, s3Error("upload part", upload.Key, err)
+	}
+	return &gostorage.Part{
+		Number: number,
+		ETag:   unquoteETag(aws.StringValue(res.ETag)),
+		Size:   size,
+	}, nil
+}
+
+// remainingSize returns the number of bytes between the current offset and the end of rs.
+func remainingSize(rs io.ReadSeeker) (int64, error) {
+	offset, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	_, err = rs.Seek(offset, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	return end - offset, nil
+}
+
+// ListParts returns the parts of the multipart upload uploaded so far.
+func (s3def S3) ListParts(upload *gostorage.Upload) ([]gostorage.Part, error) {
+	return s3def.ListPartsContext(context.Background(), upload)
+}
+
+// ListPartsContext returns the parts of the multipart upload uploaded so far.
+func (s3def S3) ListPartsContext(ctx context.Context, upload *gostorage.Upload) ([]gostorage.Part, error) {
+	parts := []gostorage.Part{}
+	err := s3def.conn.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:   &s3def.Bucket,
+		Key:      aws.String(s3def.objectKey(upload.Key)),
+		UploadId: &upload.UploadID,
+	}, func(lpo *s3.ListPartsOutput, lastPage bool) bool {
+		for _, p := range lpo.Parts {
+			parts = append(parts, gostorage.Part{
+				Number: int(aws.Int64Value(p.PartNumber)),
+				ETag:   unquoteETag(aws.StringValue(p.ETag)),
+				Size:   aws.Int64Value(p.Size),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, s3Error("list parts", upload.Key, err)
+	}
+	return parts, nil
+}
+
+// CompleteUpload concatenates the parts to the object.
+func (s3def S3) CompleteUpload(upload *gostorage.Upload, parts []gostorage.Part) error {
+	return s3def.CompleteUploadContext(context.Background(), upload, parts)
+}
+
+// CompleteUploadContext concatenates the parts to the object.
+// All parts but the last one must be at least 5 MiB.
+func (s3def S3) CompleteUploadContext(ctx context.Context, upload *gostorage.Upload, parts []gostorage.Part) error {
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(`"` + unquoteETag(part.ETag) + `"`),
+			PartNumber: aws.Int64(int64(part.Number)),
+		})
+	}
+	_, err := s3def.conn.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   &s3def.Bucket,
+		Key:      aws.String(s3def.objectKey(upload.Key)),
+		UploadId: &upload.UploadID,
+		MultipartUpload: &s3.CompletedMultipartUpload{
+			Parts: completed,
+		},
+	})
+	if err != nil {
+		return s3Error("complete upload", upload.Key, err)
+	}
+	return nil
+}
+
+// AbortUpload aborts the multipart upload and deletes the uploaded parts.
+func (s3def S3) AbortUpload(upload *gostorage.Upload) error {
+	return s3def.AbortUploadContext(context.Background(), upload)
+}
+
+// AbortUploadContext aborts the multipart upload and deletes the uploaded parts.
+func (s3def S3) AbortUploadContext(ctx context.Context, upload *gostorage.Upload) error {
+	_, err := s3def.conn.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &s3def.Bucket,
+		Key:      aws.String(s3def.objectKey(upload.Key)),
+		UploadId: &upload.UploadID,
+	})
+	if err != nil {
+		return s3Error("abort upload", upload.Key, err)
+	}
+	return nil
+}
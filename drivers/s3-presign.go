@@ -0,0 +1,37 @@
+package drivers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// SignURL returns a presigned URL that allows GET or PUT requests on the object
+// until the expiry has passed. The expiry must not exceed seven days.
+func (s3def S3) SignURL(method, key string, expiry time.Duration) (string, error) {
+	objectKey := s3def.objectKey(key)
+	var req *request.Request
+	switch method {
+	case http.MethodGet:
+		req, _ = s3def.conn.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: &s3def.Bucket,
+			Key:    &objectKey,
+		})
+	case http.MethodPut:
+		req, _ = s3def.conn.PutObjectRequest(&s3.PutObjectInput{
+			Bucket: &s3def.Bucket,
+			Key:    &objectKey,
+		})
+	default:
+		return "", s3Error("sign", key, fmt.Errorf("%w: method %s", gostorage.ErrNotSupported, method))
+	}
+	signedURL, err := req.Presign(expiry)
+	if err != nil {
+		return "", s3Error("sign", key, err)
+	}
+	return signedURL, nil
+}
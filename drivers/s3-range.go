@@ -0,0 +1,114 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// ReadRange returns the byte range of the object.
+// The caller must close the returned reader to release the connection.
+func (s3def S3) ReadRange(key string, offset, length int64) (io.ReadCloser, error) {
+	return s3def.ReadRangeContext(context.Background(), key, offset, length)
+}
+
+// ReadRangeContext returns the byte range of the object requested by the Range header.
+// The context applies to the whole download, not only to the request.
+// The caller must close the returned reader to release the connection.
+func (s3def S3) ReadRangeContext(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	err := validateRange(offset, length)
+	if err != nil {
+		return nil, s3Error("read", key, err)
+	}
+	res, err := s3def.conn.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: &s3def.Bucket,
+		Key:    aws.String(s3def.objectKey(key)),
+		Range:  aws.String(httpRange(offset, length)),
+	})
+	if err != nil {
+		return nil, s3Error("read", key, err)
+	}
+	return res.Body, nil
+}
+
+// OpenReaderAt returns random access to the content of the object.
+// Every call of ReadAt issues a ranged GetObject request.
+func (s3def S3) OpenReaderAt(key string) (gostorage.ReadAtCloser, error) {
+	return s3def.OpenReaderAtContext(context.Background(), key)
+}
+
+// OpenReaderAtContext returns random access to the content of the object.
+// Every call of ReadAt issues a ranged GetObject request with the given context.
+// The requests fail if the object has been replaced after it has been opened.
+func (s3def S3) OpenReaderAtContext(ctx context.Context, key string) (gostorage.ReadAtCloser, error) {
+	ho, err := s3def.conn.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: &s3def.Bucket,
+		Key:    aws.String(s3def.objectKey(key)),
+	})
+	if err != nil {
+		return nil, s3Error("read", key, err)
+	}
+	return &s3ReaderAt{
+		ctx:   ctx,
+		s3def: s3def,
+		key:   key,
+		etag:  ho.ETag,
+		size:  aws.Int64Value(ho.ContentLength),
+	}, nil
+}
+
+// s3ReaderAt provides random access to the content of an object.
+type s3ReaderAt struct {
+	ctx   context.Context
+	s3def S3
+	key   string
+	etag  *string
+	size  int64
+}
+
+func (r *s3ReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, s3Error("read", r.key, fmt.Errorf("%w: negative offset %d", gostorage.ErrInvalidRange, off))
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	length := int64(len(p))
+	if off+length > r.size {
+		length = r.size - off
+	}
+	res, err := r.s3def.conn.GetObjectWithContext(r.ctx, &s3.GetObjectInput{
+		Bucket:  &r.s3def.Bucket,
+		Key:     aws.String(r.s3def.objectKey(r.key)),
+		Range:   aws.String(httpRange(off, length)),
+		IfMatch: r.etag,
+	})
+	if err != nil {
+		return 0, s3Error("read", r.key, err)
+	}
+	defer res.Body.Close()
+	n, err := io.ReadFull(res.Body, p[:length])
+	if err != nil {
+		return n, s3Error("read", r.key, err)
+	}
+	if n < len(p) {
+		// io.ReaderAt requires an error if less than len(p) bytes are read
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *s3ReaderAt) Size() int64 {
+	return r.size
+}
+
+func (r *s3ReaderAt) Close() error {
+	return nil
+}
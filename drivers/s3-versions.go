@@ -0,0 +1,110 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
+)
+
+// ListVersions returns the versions and delete markers of the object, newest first.
+// Objects in buckets without versioning only have the null version.
+func (s3def S3) ListVersions(key string) ([]gostorage.ObjectVersion, error) {
+	return s3def.ListVersionsContext(context.Background(), key)
+}
+
+// ListVersionsContext returns the versions and delete markers of the object, newest first.
+// Objects in buckets without versioning only have the null version.
+func (s3def S3) ListVersionsContext(ctx context.Context, key string) ([]gostorage.ObjectVersion, error) {
+	objectKey := s3def.objectKey(key)
+	versions := []gostorage.ObjectVersion{}
+	err := s3def.conn.ListObjectVersionsPagesWithContext(ctx, &s3.ListObjectVersionsInput{
+		Bucket: &s3def.Bucket,
+		Prefix: &objectKey,
+	}, func(lovo *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		// the prefix also selects the objects whose keys start with the key
+		for _, v := range lovo.Versions {
+			if aws.StringValue(v.Key) == objectKey {
+				versions = append(versions, gostorage.ObjectVersion{
+					Key:          key,
+					VersionID:    aws.StringValue(v.VersionId),
+					Size:         aws.Int64Value(v.Size),
+					LastModified: aws.TimeValue(v.LastModified),
+					ETag:         unquoteETag(aws.StringValue(v.ETag)),
+					IsLatest:     aws.BoolValue(v.IsLatest),
+				})
+			}
+		}
+		for _, m := range lovo.DeleteMarkers {
+			if aws.StringValue(m.Key) == objectKey {
+				versions = append(versions, gostorage.ObjectVersion{
+					Key:          key,
+					VersionID:    aws.StringValue(m.VersionId),
+					LastModified: aws.TimeValue(m.LastModified),
+					IsLatest:     aws.BoolValue(m.IsLatest),
+					DeleteMarker: true,
+				})
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, s3Error("list versions", key, err)
+	}
+	if len(versions) == 0 {
+		return nil, s3Error("list versions", key, fmt.Errorf("%w: no versions of %s", gostorage.ErrNotExist, key))
+	}
+	// versions and delete markers are returned separately, the latest one comes first
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].IsLatest != versions[j].IsLatest {
+			return versions[i].IsLatest
+		}
+		return versions[i].LastModified.After(versions[j].LastModified)
+	})
+	return versions, nil
+}
+
+// ReadVersion returns the body of the version of the object without buffering it.
+// The caller must close the returned reader to release the connection.
+func (s3def S3) ReadVersion(key, versionID string) (io.ReadCloser, error) {
+	return s3def.ReadVersionContext(context.Background(), key, versionID)
+}
+
+// ReadVersionContext returns the body of the version of the object without buffering it.
+// The context applies to the whole download, not only to the request.
+// The caller must close the returned reader to release the connection.
+func (s3def S3) ReadVersionContext(ctx context.Context, key, versionID string) (io.ReadCloser, error) {
+	res, err := s3def.conn.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket:    &s3def.Bucket,
+		Key:       aws.String(s3def.objectKey(key)),
+		VersionId: &versionID,
+	})
+	if err != nil {
+		return nil, s3Error("read", key, err)
+	}
+	return res.Body, nil
+}
+
+// DeleteVersion deletes the version of the object permanently.
+// If the latest version is deleted, the previous version becomes the latest one.
+func (s3def S3) DeleteVersion(key, versionID string) error {
+	return s3def.DeleteVersionContext(context.Background(), key, versionID)
+}
+
+// DeleteVersionContext deletes the version of the object permanently.
+// If the latest version is deleted, the previous version becomes the latest one.
+func (s3def S3) DeleteVersionContext(ctx context.Context, key, versionID string) error {
+	_, err := s3def.conn.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket:    &s3def.Bucket,
+		Key:       aws.String(s3def.objectKey(key)),
+		VersionId: &versionID,
+	})
+	if err != nil {
+		return s3Error("delete", key, err)
+	}
+	return nil
+}
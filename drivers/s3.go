@@ -2,15 +2,23 @@ package drivers
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
 	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
 )
 
@@ -21,12 +29,15 @@ type S3Config struct {
 	// Region is the region of the S3 service.
 	Region string
 	// AccessKeyID is the access key ID of the S3 service.
+	// If neither AccessKeyID nor SecretAccessKey is set, the default credential chain
+	// of the aws sdk is used, e.g. the environment or the instance role.
 	AccessKeyID string
 	// SecretAccessKey is the secret access key of the S3 service.
 	SecretAccessKey string
 	// Bucket is the bucket name of the S3 service.
 	Bucket string
 	// PathPrefix is the path prefix of the S3 service.
+	// All keys are stored below the prefix, see S3.PathPrefix.
 	PathPrefix string
 	// DisableSSL defines whether to disable SSL or not.
 	DisableSSL bool
@@ -34,30 +45,50 @@ type S3Config struct {
 
 // S3 defines the interface "Driver" implementation for the s3 protocol.
 type S3 struct {
-	Bucket     string
+	Bucket string
+	// PathPrefix is the namespace of the driver within the bucket. It is prepended
+	// to the keys of all operations, separated by a "/", and stripped from the keys
+	// returned by the listings, so that multiple applications can share a bucket.
 	PathPrefix string
 
 	conn    *s3.S3
 	session *session.Session
 }
 
+// s3VersioningTimeout limits the request determining whether versioning is enabled
+// for a bucket, as Capabilities does not take a context.
+const s3VersioningTimeout = 10 * time.Second
+
+// bucketVersioning caches whether versioning is enabled per bucketRef. Failed requests
+// are not cached, so that they are retried by the next call.
+var bucketVersioning sync.Map
+
+// bucketRef identifies a bucket of a connection.
+type bucketRef struct {
+	conn   *s3.S3
+	bucket string
+}
+
 // NewS3FromConfig creates a new S3 instance from the given configuration.
 func NewS3FromConfig(config S3Config) (*S3, error) {
 	s3Def := &S3{
 		Bucket:     config.Bucket,
 		PathPrefix: config.PathPrefix,
 	}
-	sess, err := session.NewSession(&aws.Config{
-		Credentials: credentials.NewStaticCredentials(
-			config.AccessKeyID,
-			config.SecretAccessKey,
-			"",
-		),
+	awsConfig := &aws.Config{
 		Endpoint:         &config.Endpoint,
 		Region:           &config.Region,
 		DisableSSL:       &config.DisableSSL,
 		S3ForcePathStyle: aws.Bool(true),
-	})
+	}
+	if config.AccessKeyID != "" || config.SecretAccessKey != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(
+			config.AccessKeyID,
+			config.SecretAccessKey,
+			"",
+		)
+	}
+	sess, err := session.NewSession(awsConfig)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create aws session by config: %w", err)
 	}
@@ -76,74 +107,408 @@ func NewS3(bucket, pathPrefix string, conn *s3.S3, session *session.Session) *S3
 	}
 }
 
+// prefix returns the path prefix including the trailing "/", or an empty string
+// if the driver has no path prefix.
+func (s3def S3) prefix() string {
+	prefix := strings.Trim(s3def.PathPrefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// objectKey returns the key of the object within the bucket.
+func (s3def S3) objectKey(key string) string {
+	return s3def.prefix() + key
+}
+
+// trimKey returns the key of the object within the bucket without the path prefix.
+func (s3def S3) trimKey(key string) string {
+	return strings.TrimPrefix(key, s3def.prefix())
+}
+
+// Capabilities returns the optional features supported by the S3 driver.
+// CapabilityVersioning is only reported if versioning is enabled for the bucket,
+// which is requested from the service. HasCapability avoids the request for
+// the other capabilities.
+func (s3def S3) Capabilities() gostorage.Capability {
+	return s3def.baseCapabilities() | s3def.versioningCapability()
+}
+
+// HasCapability reports whether the S3 driver supports all capabilities of c.
+// Whether versioning is enabled for the bucket is only requested if c contains
+// CapabilityVersioning.
+func (s3def S3) HasCapability(c gostorage.Capability) bool {
+	capabilities := s3def.baseCapabilities()
+	if c.Has(gostorage.CapabilityVersioning) {
+		capabilities |= s3def.versioningCapability()
+	}
+	return capabilities.Has(c)
+}
+
+// baseCapabilities returns the capabilities supported regardless of the bucket.
+func (s3def S3) baseCapabilities() gostorage.Capability {
+	return gostorage.CapabilityContext | gostorage.CapabilityStreamRead | gostorage.CapabilityRangeRead |
+		gostorage.CapabilityStat | gostorage.CapabilityWriteOptions | gostorage.CapabilityConditionalWrite |
+		gostorage.CapabilityHierarchicalList | gostorage.CapabilityCopy | gostorage.CapabilityMove |
+		gostorage.CapabilityBatchDelete | gostorage.CapabilityPrefixDelete | gostorage.CapabilityPresignedURL |
+		gostorage.CapabilityMultipartUpload
+}
+
+// versioningCapability returns gostorage.CapabilityVersioning if versioning is enabled
+// for the bucket. The status is requested by GetBucketVersioning once per connection and
+// bucket. If the request fails, versioning is reported as disabled until the next call.
+func (s3def S3) versioningCapability() gostorage.Capability {
+	ref := bucketRef{conn: s3def.conn, bucket: s3def.Bucket}
+	enabled, ok := bucketVersioning.Load(ref)
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), s3VersioningTimeout)
+		defer cancel()
+		res, err := s3def.conn.GetBucketVersioningWithContext(ctx, &s3.GetBucketVersioningInput{
+			Bucket: &s3def.Bucket,
+		})
+		if err != nil {
+			return 0
+		}
+		enabled = aws.StringValue(res.Status) == s3.BucketVersioningStatusEnabled
+		bucketVersioning.Store(ref, enabled)
+	}
+	if !enabled.(bool) {
+		return 0
+	}
+	return gostorage.CapabilityVersioning
+}
+
 // Read reads the file/object and returns the content.
 func (s3def S3) Read(key string) (io.Reader, error) {
-	res, err := s3def.conn.GetObject(&s3.GetObjectInput{
-		Bucket: &s3def.Bucket,
-		Key:    aws.String(key),
-	})
+	return s3def.ReadContext(context.Background(), key)
+}
+
+// ReadContext reads the file/object and returns the content.
+func (s3def S3) ReadContext(ctx context.Context, key string) (io.Reader, error) {
+	body, err := s3def.ReadStreamContext(ctx, key)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read object %q: %w", key, err)
+		return nil, err
 	}
-	defer res.Body.Close()
-	bts, err := ioutil.ReadAll(res.Body)
+	defer body.Close()
+	bts, err := ioutil.ReadAll(body)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read bytes from object %q: %w", key, err)
+		return nil, s3Error("read", key, err)
 	}
 	return bytes.NewBuffer(bts), nil
 }
 
+// ReadStream returns the body of the object without buffering it.
+// The caller must close the returned reader to release the connection.
+func (s3def S3) ReadStream(key string) (io.ReadCloser, error) {
+	return s3def.ReadStreamContext(context.Background(), key)
+}
+
+// ReadStreamContext returns the body of the object without buffering it.
+// The context applies to the whole download, not only to the request.
+// The caller must close the returned reader to release the connection.
+func (s3def S3) ReadStreamContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := s3def.conn.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: &s3def.Bucket,
+		Key:    aws.String(s3def.objectKey(key)),
+	})
+	if err != nil {
+		return nil, s3Error("read", key, err)
+	}
+	return res.Body, nil
+}
+
 func (s3def S3) Write(key string, value io.Reader) error {
-	mType, err := utils.MimeType(value)
+	return s3def.WriteContext(context.Background(), key, value)
+}
+
+func (s3def S3) WriteContext(ctx context.Context, key string, value io.Reader) error {
+	return s3def.WriteWithOptionsContext(ctx, key, value, gostorage.WriteOptions{})
+}
+
+// WriteWithOptions uploads the value to the object.
+// The attributes defined by opts are stored as object headers and metadata.
+func (s3def S3) WriteWithOptions(key string, value io.Reader, opts gostorage.WriteOptions) error {
+	return s3def.WriteWithOptionsContext(context.Background(), key, value, opts)
+}
+
+// WriteWithOptionsContext uploads the value to the object.
+// The attributes defined by opts are stored as object headers and metadata.
+// The conditions of opts are sent as If-None-Match and If-Match headers.
+func (s3def S3) WriteWithOptionsContext(ctx context.Context, key string, value io.Reader, opts gostorage.WriteOptions) error {
+	err := validateConditions(opts)
 	if err != nil {
-		return err
-	}
-	uploader := s3manager.NewUploader(s3def.session)
-	_, err = uploader.Upload(&s3manager.UploadInput{
-		Bucket:      &s3def.Bucket,
-		Key:         &key,
-		Body:        value,
-		ContentType: &mType,
+		return s3Error("write", key, err)
+	}
+	mType := opts.ContentType
+	if mType == "" {
+		// the bytes read for the detection are replayed, so that they are uploaded as well
+		mType, value, err = utils.PeekMimeType(value)
+		if err != nil {
+			return s3Error("write", key, err)
+		}
+	}
+	uploader := s3manager.NewUploader(s3def.session, s3manager.WithUploaderRequestOptions(conditionalWrite(opts)))
+	_, err = uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:             &s3def.Bucket,
+		Key:                aws.String(s3def.objectKey(key)),
+		Body:               value,
+		ContentType:        &mType,
+		CacheControl:       optionalString(opts.CacheControl),
+		ContentDisposition: optionalString(opts.ContentDisposition),
+		ContentEncoding:    optionalString(opts.ContentEncoding),
+		Metadata:           objectMetadata(opts.Metadata),
 	})
+	var aerr awserr.Error
+	if opts.IfMatch != "" && errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+		// S3 reports a missing object instead of a failed precondition
+		err = fmt.Errorf("%w: %v", gostorage.ErrPreconditionFailed, err)
+	}
 	if err != nil {
-		return fmt.Errorf("unable to upload %q: %w", key, err)
+		return s3Error("write", key, err)
 	}
 	return nil
 }
 
+// conditionalWrite returns the request option that adds the conditions of opts
+// to the requests creating the object. The aws sdk does not support them natively.
+func conditionalWrite(opts gostorage.WriteOptions) request.Option {
+	return func(r *request.Request) {
+		switch r.Operation.Name {
+		case "PutObject", "CompleteMultipartUpload":
+		default:
+			return
+		}
+		if opts.IfNotExists {
+			r.HTTPRequest.Header.Set("If-None-Match", "*")
+		}
+		if opts.IfMatch != "" {
+			r.HTTPRequest.Header.Set("If-Match", `"`+unquoteETag(opts.IfMatch)+`"`)
+		}
+	}
+}
+
 func (s3def S3) Delete(key string) error {
-	_, err := s3def.conn.DeleteObject(&s3.DeleteObjectInput{
+	return s3def.DeleteContext(context.Background(), key)
+}
+
+func (s3def S3) DeleteContext(ctx context.Context, key string) error {
+	_, err := s3def.conn.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
 		Bucket: &s3def.Bucket,
-		Key:    &key,
+		Key:    aws.String(s3def.objectKey(key)),
 	})
 	if err != nil {
-		return fmt.Errorf("unable to delete object %q: %w", key, err)
+		return s3Error("delete", key, err)
 	}
 	return nil
 }
 
+// maxDeleteObjects is the maximum number of keys of a single DeleteObjects request.
+// It is a variable, so that the tests can use smaller batches.
+var maxDeleteObjects = 1000
+
+// DeleteMany deletes the objects identified by keys with DeleteObjects requests.
+// The returned map contains the error of every key that could not be deleted.
+// Missing objects are not an error.
+func (s3def S3) DeleteMany(keys []string) map[string]error {
+	return s3def.DeleteManyContext(context.Background(), keys)
+}
+
+// DeleteManyContext deletes the objects identified by keys with DeleteObjects requests
+// of up to 1000 keys each. The returned map contains the error of every key that could
+// not be deleted. Missing objects are not an error.
+func (s3def S3) DeleteManyContext(ctx context.Context, keys []string) map[string]error {
+	errs := map[string]error{}
+	for start := 0; start < len(keys); start += maxDeleteObjects {
+		end := start + maxDeleteObjects
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+
+		objects := make([]*s3.ObjectIdentifier, 0, len(batch))
+		for _, key := range batch {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(s3def.objectKey(key))})
+		}
+		res, err := s3def.conn.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: &s3def.Bucket,
+			Delete: &s3.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true),
+			},
+		})
+		if err != nil {
+			for _, key := range batch {
+				errs[key] = s3Error("delete", key, err)
+			}
+			continue
+		}
+		for _, e := range res.Errors {
+			key := s3def.trimKey(aws.StringValue(e.Key))
+			errs[key] = s3Error("delete", key, awserr.New(aws.StringValue(e.Code), aws.StringValue(e.Message), nil))
+		}
+	}
+	return errs
+}
+
+// DeletePrefix deletes all objects whose keys start with prefix and returns their keys.
+// An empty prefix is rejected with gostorage.ErrInvalidKey.
+func (s3def S3) DeletePrefix(prefix string, opts gostorage.DeletePrefixOptions) ([]string, error) {
+	return s3def.DeletePrefixContext(context.Background(), prefix, opts)
+}
+
+// DeletePrefixContext deletes all objects whose keys start with prefix and returns their keys.
+// The objects are listed and deleted page by page. An empty prefix is rejected with gostorage.ErrInvalidKey.
+func (s3def S3) DeletePrefixContext(ctx context.Context, prefix string, opts gostorage.DeletePrefixOptions) ([]string, error) {
+	return deletePrefix(ctx, s3def, s3DriverName, prefix, opts)
+}
+
+// Exists reports whether the object exists. A missing object is not an error.
 func (s3def S3) Exists(key string) (bool, error) {
-	ho, err := s3def.conn.HeadObject(&s3.HeadObjectInput{
+	return s3def.ExistsContext(context.Background(), key)
+}
+
+// ExistsContext reports whether the object exists. A missing object is not an error.
+func (s3def S3) ExistsContext(ctx context.Context, key string) (bool, error) {
+	_, err := s3def.conn.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
 		Bucket: &s3def.Bucket,
-		Key:    &key,
+		Key:    aws.String(s3def.objectKey(key)),
 	})
 	if err != nil {
-		return false, fmt.Errorf("unable to check if object %q exists: %w", key, err)
+		err = s3Error("exists", key, err)
+		if errors.Is(err, gostorage.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
 	}
-	return *ho.ContentLength > 0, nil
+	return true, nil
 }
 
 func (s3def S3) List() ([]string, error) {
-	loo, err := s3def.conn.ListObjectsV2(&s3.ListObjectsV2Input{
+	return s3def.ListContext(context.Background())
+}
+
+func (s3def S3) ListContext(ctx context.Context) ([]string, error) {
+	var keys []string
+	err := s3def.conn.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
 		Bucket: &s3def.Bucket,
-		Prefix: &s3def.PathPrefix,
+		Prefix: optionalString(s3def.prefix()),
+	}, func(loo *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, o := range loo.Contents {
+			keys = append(keys, s3def.trimKey(aws.StringValue(o.Key)))
+		}
+		return true
 	})
 	if err != nil {
-		return nil, fmt.Errorf("unable to list objects: %w", err)
+		return nil, s3Error("list", "", err)
 	}
-	var keys []string
+	return keys, nil
+}
+
+// ListPage returns a single page of the objects selected by opts.
+func (s3def S3) ListPage(opts gostorage.ListOptions) (*gostorage.ListResult, error) {
+	return s3def.ListPageContext(context.Background(), opts)
+}
+
+// ListPageContext returns a single page of the objects selected by opts.
+// The prefix and the start key of opts are relative to the path prefix of the driver.
+func (s3def S3) ListPageContext(ctx context.Context, opts gostorage.ListOptions) (*gostorage.ListResult, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = gostorage.DefaultPageSize
+	}
+	startAfter := opts.StartAfter
+	if startAfter != "" {
+		startAfter = s3def.objectKey(startAfter)
+	}
+	loo, err := s3def.conn.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:            &s3def.Bucket,
+		Prefix:            optionalString(s3def.objectKey(opts.Prefix)),
+		Delimiter:         optionalString(opts.Delimiter),
+		StartAfter:        optionalString(startAfter),
+		ContinuationToken: optionalString(opts.ContinuationToken),
+		MaxKeys:           aws.Int64(int64(pageSize)),
+	})
+	if err != nil {
+		return nil, s3Error("list", opts.Prefix, err)
+	}
+	res := &gostorage.ListResult{}
 	for _, o := range loo.Contents {
-		keys = append(keys, *o.Key)
+		res.Objects = append(res.Objects, gostorage.ObjectInfo{
+			Key:          s3def.trimKey(aws.StringValue(o.Key)),
+			Size:         aws.Int64Value(o.Size),
+			LastModified: aws.TimeValue(o.LastModified),
+			ETag:         unquoteETag(aws.StringValue(o.ETag)),
+		})
 	}
-	return keys, nil
+	for _, p := range loo.CommonPrefixes {
+		res.CommonPrefixes = append(res.CommonPrefixes, s3def.trimKey(aws.StringValue(p.Prefix)))
+	}
+	if aws.BoolValue(loo.IsTruncated) {
+		res.NextContinuationToken = aws.StringValue(loo.NextContinuationToken)
+	}
+	return res, nil
+}
+
+// Stat returns the metadata of the object.
+func (s3def S3) Stat(key string) (*gostorage.ObjectInfo, error) {
+	return s3def.StatContext(context.Background(), key)
+}
+
+// StatContext returns the metadata of the object.
+func (s3def S3) StatContext(ctx context.Context, key string) (*gostorage.ObjectInfo, error) {
+	ho, err := s3def.conn.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: &s3def.Bucket,
+		Key:    aws.String(s3def.objectKey(key)),
+	})
+	if err != nil {
+		return nil, s3Error("stat", key, err)
+	}
+	return &gostorage.ObjectInfo{
+		Key:                key,
+		Size:               aws.Int64Value(ho.ContentLength),
+		LastModified:       aws.TimeValue(ho.LastModified),
+		ContentType:        aws.StringValue(ho.ContentType),
+		CacheControl:       aws.StringValue(ho.CacheControl),
+		ContentDisposition: aws.StringValue(ho.ContentDisposition),
+		ContentEncoding:    aws.StringValue(ho.ContentEncoding),
+		ETag:               unquoteETag(aws.StringValue(ho.ETag)),
+		Metadata:           metadata(ho.Metadata),
+		VersionID:          aws.StringValue(ho.VersionId),
+	}, nil
+}
+
+// objectMetadata converts the user defined metadata to the metadata of an object.
+// S3 treats the keys case insensitive, therefore they are converted to lower case.
+func objectMetadata(m map[string]string) map[string]*string {
+	if len(m) == 0 {
+		return nil
+	}
+	md := make(map[string]*string, len(m))
+	for k, v := range m {
+		md[strings.ToLower(k)] = aws.String(v)
+	}
+	return md
+}
+
+// metadata converts the user defined metadata of an object.
+// S3 treats the keys case insensitive, therefore they are converted to lower case.
+func metadata(m map[string]*string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	md := make(map[string]string, len(m))
+	for k, v := range m {
+		md[strings.ToLower(k)] = aws.StringValue(v)
+	}
+	return md
+}
+
+// optionalString returns a pointer to s, or nil if s is empty,
+// so that empty attributes are not sent as empty headers.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
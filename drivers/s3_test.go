@@ -1,17 +1,25 @@
 package drivers
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
 	"github.com/orlangure/gnomock"
 	"github.com/orlangure/gnomock/preset/localstack"
 )
@@ -77,9 +85,28 @@ func TestNewS3FromConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "default credentials",
+			args: args{
+				config: S3Config{
+					Endpoint:   "http://localhost:4572",
+					Region:     "eu-central-1",
+					Bucket:     "sample-bucket",
+					PathPrefix: "",
+					DisableSSL: true,
+				},
+			},
+			want: &S3{
+				Bucket:     "sample-bucket",
+				PathPrefix: "",
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_ACCESS_KEY_ID", "env-access-key-id")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret-access-key")
 			got, err := NewS3FromConfig(tt.args.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewS3FromConfig() error = %v, wantErr %v", err, tt.wantErr)
@@ -96,6 +123,19 @@ func TestNewS3FromConfig(t *testing.T) {
 				return
 			}
 
+			creds, credErr := got.session.Config.Credentials.Get()
+			if credErr != nil {
+				t.Errorf("Credentials.Get() error = %v", credErr)
+				return
+			}
+			wantAccessKeyID := tt.args.config.AccessKeyID
+			if wantAccessKeyID == "" {
+				wantAccessKeyID = "env-access-key-id"
+			}
+			if creds.AccessKeyID != wantAccessKeyID {
+				t.Errorf("NewS3FromConfig() access key id = %v, want %v", creds.AccessKeyID, wantAccessKeyID)
+			}
+
 			if (err != nil) != tt.wantErr {
 				if got.conn == nil {
 					t.Errorf("NewS3FromConfig() conn = %v, want not nil", got)
@@ -704,6 +744,44 @@ func TestS3_Exists(t *testing.T) {
 			want:    true,
 			wantErr: false,
 		},
+		{
+			name: "empty object found",
+			fields: fields{
+				Bucket:     testBucket,
+				PathPrefix: "",
+				conn:       s3.New(awsSession),
+				session:    awsSession,
+			},
+			args: args{
+				key: "empty.txt",
+			},
+			condition: condition{
+				preCondition: func() {
+					_, err := s3.New(awsSession).PutObject(&s3.PutObjectInput{
+						Bucket: aws.String(testBucket),
+						Key:    aws.String("empty.txt"),
+						Body:   strings.NewReader(""),
+					})
+					if err != nil {
+						t.Errorf("TestS3_Exists PutObject() error = %v", err)
+						t.FailNow()
+						return
+					}
+				},
+				postCondition: func() {
+					_, err := s3.New(awsSession).DeleteObject(&s3.DeleteObjectInput{
+						Bucket: aws.String(testBucket),
+						Key:    aws.String("empty.txt"),
+					})
+					if err != nil {
+						t.Errorf("TestS3_Exists DeleteObject() error = %v", err)
+						t.FailNow()
+					}
+				},
+			},
+			want:    true,
+			wantErr: false,
+		},
 		{
 			name: "object not found",
 			fields: fields{
@@ -722,7 +800,7 @@ func TestS3_Exists(t *testing.T) {
 				},
 			},
 			want:    false,
-			wantErr: true,
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
@@ -844,3 +922,1104 @@ func TestS3_List(t *testing.T) {
 		})
 	}
 }
+
+func TestS3_cancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		call func(s3def S3) error
+	}{
+		{
+			name: "ReadContext",
+			call: func(s3def S3) error {
+				_, err := s3def.ReadContext(ctx, "test.txt")
+				return err
+			},
+		},
+		{
+			name: "WriteContext",
+			call: func(s3def S3) error {
+				return s3def.WriteContext(ctx, "test.txt", strings.NewReader("test"))
+			},
+		},
+		{
+			name: "DeleteContext",
+			call: func(s3def S3) error {
+				return s3def.DeleteContext(ctx, "test.txt")
+			},
+		},
+		{
+			name: "ExistsContext",
+			call: func(s3def S3) error {
+				_, err := s3def.ExistsContext(ctx, "test.txt")
+				return err
+			},
+		},
+		{
+			name: "ListContext",
+			call: func(s3def S3) error {
+				_, err := s3def.ListContext(ctx)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3def := S3{
+				Bucket:  testBucket,
+				conn:    s3.New(awsSession),
+				session: awsSession,
+			}
+			if err := tt.call(s3def); !errors.Is(err, context.Canceled) {
+				t.Errorf("S3.%s() error = %v, want %v", tt.name, err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestS3_ReadStream(t *testing.T) {
+	type args struct {
+		key string
+	}
+	type condition struct {
+		preCondition  func()
+		postCondition func()
+	}
+	tests := []struct {
+		name      string
+		args      args
+		condition condition
+		want      []byte
+		wantErr   bool
+	}{
+		{
+			name: "object found",
+			args: args{
+				key: "test.txt",
+			},
+			condition: condition{
+				preCondition: func() {
+					_, err := s3.New(awsSession).PutObject(&s3.PutObjectInput{
+						Bucket: aws.String(testBucket),
+						Key:    aws.String("test.txt"),
+						Body:   strings.NewReader("test"),
+					})
+					if err != nil {
+						t.Errorf("PutObject() error = %v", err)
+					}
+				},
+				postCondition: func() {
+					_, err := s3.New(awsSession).DeleteObject(&s3.DeleteObjectInput{
+						Bucket: aws.String(testBucket),
+						Key:    aws.String("test.txt"),
+					})
+					if err != nil {
+						t.Errorf("DeleteObject() error = %v", err)
+					}
+				},
+			},
+			want:    []byte("test"),
+			wantErr: false,
+		},
+		{
+			name: "object not found",
+			args: args{
+				key: "test.txt",
+			},
+			condition: condition{
+				preCondition:  func() {},
+				postCondition: func() {},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.condition.preCondition()
+			defer tt.condition.postCondition()
+			s3def := S3{
+				Bucket:  testBucket,
+				conn:    s3.New(awsSession),
+				session: awsSession,
+			}
+			got, err := s3def.ReadStream(tt.args.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("S3.ReadStream() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got == nil {
+				return
+			}
+			defer got.Close()
+
+			bts, err := ioutil.ReadAll(got)
+			if err != nil {
+				t.Errorf("S3.ReadStream() error reading object: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(bts, tt.want) {
+				t.Errorf("S3.ReadStream() = %v, want %v", bts, tt.want)
+			}
+		})
+	}
+}
+
+func TestS3_ReadStream_largeObject(t *testing.T) {
+	const size int64 = 64 << 20
+
+	svc := s3.New(awsSession)
+	_, err := svc.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(testBucket),
+		Key:    aws.String("large.bin"),
+		Body:   bytes.NewReader(make([]byte, size)),
+	})
+	if err != nil {
+		t.Errorf("PutObject() error = %v", err)
+		return
+	}
+	defer func() {
+		_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(testBucket),
+			Key:    aws.String("large.bin"),
+		})
+		if err != nil {
+			t.Errorf("DeleteObject() error = %v", err)
+		}
+	}()
+
+	s3def := S3{
+		Bucket:  testBucket,
+		conn:    svc,
+		session: awsSession,
+	}
+	var n int64
+	allocated := allocatedBytes(func() {
+		rc, err := s3def.ReadStream("large.bin")
+		if err != nil {
+			t.Errorf("S3.ReadStream() error = %v", err)
+			return
+		}
+		defer rc.Close()
+		n, err = io.Copy(ioutil.Discard, rc)
+		if err != nil {
+			t.Errorf("S3.ReadStream() error reading object: %v", err)
+		}
+	})
+	if n != size {
+		t.Errorf("S3.ReadStream() read %d bytes, want %d", n, size)
+	}
+	if allocated > maxStreamAllocation {
+		t.Errorf("S3.ReadStream() allocated %d bytes, want at most %d", allocated, maxStreamAllocation)
+	}
+}
+
+func TestS3_Stat(t *testing.T) {
+	type args struct {
+		key string
+	}
+	type condition struct {
+		preCondition  func()
+		postCondition func()
+	}
+	tests := []struct {
+		name      string
+		args      args
+		condition condition
+		want      *gostorage.ObjectInfo
+		wantErr   bool
+	}{
+		{
+			name: "object found",
+			args: args{
+				key: "test.txt",
+			},
+			condition: condition{
+				preCondition: func() {
+					_, err := s3.New(awsSession).PutObject(&s3.PutObjectInput{
+						Bucket:      aws.String(testBucket),
+						Key:         aws.String("test.txt"),
+						Body:        strings.NewReader("test"),
+						ContentType: aws.String("text/plain"),
+						Metadata: map[string]*string{
+							"Owner": aws.String("tester"),
+						},
+					})
+					if err != nil {
+						t.Errorf("PutObject() error = %v", err)
+					}
+				},
+				postCondition: func() {
+					_, err := s3.New(awsSession).DeleteObject(&s3.DeleteObjectInput{
+						Bucket: aws.String(testBucket),
+						Key:    aws.String("test.txt"),
+					})
+					if err != nil {
+						t.Errorf("DeleteObject() error = %v", err)
+					}
+				},
+			},
+			want: &gostorage.ObjectInfo{
+				Key:         "test.txt",
+				Size:        4,
+				ContentType: "text/plain",
+				ETag:        "098f6bcd4621d373cade4e832627b4f6",
+				Metadata: map[string]string{
+					"owner": "tester",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "object not found",
+			args: args{
+				key: "test.txt",
+			},
+			condition: condition{
+				preCondition:  func() {},
+				postCondition: func() {},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.condition.preCondition()
+			defer tt.condition.postCondition()
+			s3def := S3{
+				Bucket:  testBucket,
+				conn:    s3.New(awsSession),
+				session: awsSession,
+			}
+			got, err := s3def.Stat(tt.args.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("S3.Stat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got == nil {
+				return
+			}
+			if got.LastModified.IsZero() {
+				t.Errorf("S3.Stat() LastModified is zero")
+			}
+			got.LastModified = time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("S3.Stat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestS3_WriteWithOptions(t *testing.T) {
+	type args struct {
+		key   string
+		value io.Reader
+		opts  gostorage.WriteOptions
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *gostorage.ObjectInfo
+		wantErr bool
+	}{
+		{
+			name: "with attributes",
+			args: args{
+				key:   "test.txt",
+				value: strings.NewReader("test"),
+				opts: gostorage.WriteOptions{
+					ContentType:        "application/x-test",
+					CacheControl:       "no-cache",
+					ContentDisposition: "attachment",
+					ContentEncoding:    "identity",
+					Metadata: map[string]string{
+						"Owner": "tester",
+					},
+				},
+			},
+			want: &gostorage.ObjectInfo{
+				Key:                "test.txt",
+				Size:               4,
+				ContentType:        "application/x-test",
+				CacheControl:       "no-cache",
+				ContentDisposition: "attachment",
+				ContentEncoding:    "identity",
+				ETag:               "098f6bcd4621d373cade4e832627b4f6",
+				Metadata: map[string]string{
+					"owner": "tester",
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3def := S3{
+				Bucket:  testBucket,
+				conn:    s3.New(awsSession),
+				session: awsSession,
+			}
+			err := s3def.WriteWithOptions(tt.args.key, tt.args.value, tt.args.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("S3.WriteWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			defer func() {
+				_, err := s3.New(awsSession).DeleteObject(&s3.DeleteObjectInput{
+					Bucket: aws.String(testBucket),
+					Key:    aws.String(tt.args.key),
+				})
+				if err != nil {
+					t.Errorf("DeleteObject() error = %v", err)
+				}
+			}()
+
+			got, err := s3def.Stat(tt.args.key)
+			if err != nil {
+				t.Errorf("S3.Stat() error = %v", err)
+				return
+			}
+			got.LastModified = time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("S3.Stat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestS3_Write_detectedContentType(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         []byte
+		wantContentType string
+	}{
+		{
+			name:            "small text",
+			content:         []byte("test"),
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "text larger than the detection limit",
+			content:         bytes.Repeat([]byte("test\n"), 4096),
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "png",
+			content:         append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), make([]byte, 4096)...),
+			wantContentType: "image/png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3def := S3{
+				Bucket:  testBucket,
+				conn:    s3.New(awsSession),
+				session: awsSession,
+			}
+			// the content is not seekable, so the detection can not rewind it
+			err := s3def.Write("detected", io.MultiReader(bytes.NewReader(tt.content)))
+			if err != nil {
+				t.Errorf("S3.Write() error = %v", err)
+				return
+			}
+			defer s3def.Delete("detected")
+
+			got, err := s3def.Read("detected")
+			if err != nil {
+				t.Errorf("S3.Read() error = %v", err)
+				return
+			}
+			bts, err := ioutil.ReadAll(got)
+			if err != nil {
+				t.Errorf("S3.Read() error = %v", err)
+				return
+			}
+			if !bytes.Equal(bts, tt.content) {
+				t.Errorf("S3.Read() returned %d bytes, want the %d bytes written", len(bts), len(tt.content))
+			}
+			info, err := s3def.Stat("detected")
+			if err != nil {
+				t.Errorf("S3.Stat() error = %v", err)
+				return
+			}
+			if info.ContentType != tt.wantContentType {
+				t.Errorf("S3.Stat() content type = %v, want %v", info.ContentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestS3_List_moreThanOnePage(t *testing.T) {
+	const count = gostorage.DefaultPageSize + 1
+
+	svc := s3.New(awsSession)
+	for i := 0; i < count; i++ {
+		_, err := svc.PutObject(&s3.PutObjectInput{
+			Bucket: aws.String(testBucket),
+			Key:    aws.String(fmt.Sprintf("many/%04d.txt", i)),
+			Body:   strings.NewReader("test"),
+		})
+		if err != nil {
+			t.Errorf("PutObject() error = %v", err)
+			return
+		}
+	}
+	defer func() {
+		for i := 0; i < count; i++ {
+			_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+				Bucket: aws.String(testBucket),
+				Key:    aws.String(fmt.Sprintf("many/%04d.txt", i)),
+			})
+			if err != nil {
+				t.Errorf("DeleteObject() error = %v", err)
+			}
+		}
+	}()
+
+	s3def := S3{
+		Bucket:     testBucket,
+		PathPrefix: "many/",
+		conn:       svc,
+		session:    awsSession,
+	}
+	got, err := s3def.List()
+	if err != nil {
+		t.Errorf("S3.List() error = %v", err)
+		return
+	}
+	if len(got) != count {
+		t.Errorf("S3.List() returned %d keys, want %d", len(got), count)
+	}
+}
+
+func TestS3_ListPage(t *testing.T) {
+	type args struct {
+		opts gostorage.ListOptions
+	}
+	tests := []struct {
+		name       string
+		pathPrefix string
+		args       args
+		want       []string
+		wantPages  int
+	}{
+		{
+			name: "all objects in pages of two",
+			args: args{
+				opts: gostorage.ListOptions{Prefix: "page/", PageSize: 2},
+			},
+			want:      []string{"page/a.txt", "page/b.txt", "page/c-f.txt", "page/c/d.txt", "page/c/e.txt"},
+			wantPages: 3,
+		},
+		{
+			name: "prefix",
+			args: args{
+				opts: gostorage.ListOptions{Prefix: "page/c/"},
+			},
+			want:      []string{"page/c/d.txt", "page/c/e.txt"},
+			wantPages: 1,
+		},
+		{
+			name: "start after",
+			args: args{
+				opts: gostorage.ListOptions{Prefix: "page/", StartAfter: "page/c-f.txt", PageSize: 1},
+			},
+			want:      []string{"page/c/d.txt", "page/c/e.txt"},
+			wantPages: 2,
+		},
+		{
+			name: "no match",
+			args: args{
+				opts: gostorage.ListOptions{Prefix: "page/x"},
+			},
+			want:      []string{},
+			wantPages: 1,
+		},
+		{
+			name:       "path prefix",
+			pathPrefix: "page",
+			args: args{
+				opts: gostorage.ListOptions{StartAfter: "a.txt", PageSize: 2},
+			},
+			want:      []string{"b.txt", "c-f.txt", "c/d.txt", "c/e.txt"},
+			wantPages: 2,
+		},
+		{
+			name:       "path prefix and prefix",
+			pathPrefix: "page/",
+			args: args{
+				opts: gostorage.ListOptions{Prefix: "c/"},
+			},
+			want:      []string{"c/d.txt", "c/e.txt"},
+			wantPages: 1,
+		},
+	}
+	keys := []string{"page/a.txt", "page/b.txt", "page/c-f.txt", "page/c/d.txt", "page/c/e.txt"}
+	svc := s3.New(awsSession)
+	for _, key := range keys {
+		_, err := svc.PutObject(&s3.PutObjectInput{
+			Bucket: aws.String(testBucket),
+			Key:    aws.String(key),
+			Body:   strings.NewReader("test"),
+		})
+		if err != nil {
+			t.Errorf("PutObject() error = %v", err)
+			return
+		}
+	}
+	defer func() {
+		for _, key := range keys {
+			_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+				Bucket: aws.String(testBucket),
+				Key:    aws.String(key),
+			})
+			if err != nil {
+				t.Errorf("DeleteObject() error = %v", err)
+			}
+		}
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3def := S3{
+				Bucket:     testBucket,
+				PathPrefix: tt.pathPrefix,
+				conn:       svc,
+				session:    awsSession,
+			}
+			got, pages := listPages(t, s3def, tt.args.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("S3.ListPage() = %v, want %v", got, tt.want)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("S3.ListPage() pages = %d, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestS3_Copy(t *testing.T) {
+	type args struct {
+		src string
+		dst string
+	}
+	tests := []struct {
+		name              string
+		args              args
+		content           []byte
+		maxCopyObjectSize int64
+		wantErr           bool
+	}{
+		{
+			name: "single request",
+			args: args{
+				src: "src.txt",
+				dst: "dst.txt",
+			},
+			content:           []byte("test"),
+			maxCopyObjectSize: maxCopyObjectSize,
+			wantErr:           false,
+		},
+		{
+			name: "in parts",
+			args: args{
+				src: "src.bin",
+				dst: "dst.bin",
+			},
+			// the parts of a multipart upload must be at least 5 MiB, except the last one
+			content:           bytes.Repeat([]byte("0123456789"), 600*1024),
+			maxCopyObjectSize: 1 << 20,
+			wantErr:           false,
+		},
+		{
+			name: "source not found",
+			args: args{
+				src: "missing.txt",
+				dst: "dst.txt",
+			},
+			maxCopyObjectSize: maxCopyObjectSize,
+			wantErr:           true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := s3.New(awsSession)
+			if tt.content != nil {
+				_, err := svc.PutObject(&s3.PutObjectInput{
+					Bucket:      aws.String(testBucket),
+					Key:         aws.String(tt.args.src),
+					Body:        bytes.NewReader(tt.content),
+					ContentType: aws.String("application/x-test"),
+					Metadata:    map[string]*string{"owner": aws.String("tester")},
+				})
+				if err != nil {
+					t.Errorf("PutObject() error = %v", err)
+					return
+				}
+			}
+			defer func() {
+				for _, key := range []string{tt.args.src, tt.args.dst} {
+					_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+						Bucket: aws.String(testBucket),
+						Key:    aws.String(key),
+					})
+					if err != nil {
+						t.Errorf("DeleteObject() error = %v", err)
+					}
+				}
+			}()
+
+			defaultMaxCopyObjectSize, defaultCopyPartSize := maxCopyObjectSize, copyPartSize
+			maxCopyObjectSize, copyPartSize = tt.maxCopyObjectSize, 5<<20
+			defer func() {
+				maxCopyObjectSize, copyPartSize = defaultMaxCopyObjectSize, defaultCopyPartSize
+			}()
+
+			s3def := S3{
+				Bucket:  testBucket,
+				conn:    svc,
+				session: awsSession,
+			}
+			err := s3def.Copy(tt.args.src, tt.args.dst)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("S3.Copy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, gostorage.ErrNotExist) {
+					t.Errorf("S3.Copy() error = %v, want %v", err, gostorage.ErrNotExist)
+				}
+				return
+			}
+
+			got, err := svc.GetObject(&s3.GetObjectInput{
+				Bucket: aws.String(testBucket),
+				Key:    aws.String(tt.args.dst),
+			})
+			if err != nil {
+				t.Errorf("GetObject() error = %v", err)
+				return
+			}
+			defer got.Body.Close()
+			bts, err := ioutil.ReadAll(got.Body)
+			if err != nil {
+				t.Errorf("ReadAll() error = %v", err)
+				return
+			}
+			if !bytes.Equal(bts, tt.content) {
+				t.Errorf("S3.Copy() copied %d bytes, want %d", len(bts), len(tt.content))
+			}
+			if aws.StringValue(got.ContentType) != "application/x-test" {
+				t.Errorf("S3.Copy() ContentType = %v, want %v", aws.StringValue(got.ContentType), "application/x-test")
+			}
+			if md := metadata(got.Metadata); md["owner"] != "tester" {
+				t.Errorf("S3.Copy() Metadata = %v, want owner=tester", md)
+			}
+		})
+	}
+}
+
+func TestS3_Move(t *testing.T) {
+	svc := s3.New(awsSession)
+	_, err := svc.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(testBucket),
+		Key:    aws.String("src.txt"),
+		Body:   strings.NewReader("test"),
+	})
+	if err != nil {
+		t.Errorf("PutObject() error = %v", err)
+		return
+	}
+	defer func() {
+		_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(testBucket),
+			Key:    aws.String("dst.txt"),
+		})
+		if err != nil {
+			t.Errorf("DeleteObject() error = %v", err)
+		}
+	}()
+
+	s3def := S3{
+		Bucket:  testBucket,
+		conn:    svc,
+		session: awsSession,
+	}
+	err = s3def.Move("src.txt", "dst.txt")
+	if err != nil {
+		t.Errorf("S3.Move() error = %v", err)
+		return
+	}
+	exists, err := s3def.Exists("src.txt")
+	if err != nil || exists {
+		t.Errorf("S3.Exists(src) = %v, %v, want false, nil", exists, err)
+	}
+	exists, err = s3def.Exists("dst.txt")
+	if err != nil || !exists {
+		t.Errorf("S3.Exists(dst) = %v, %v, want true, nil", exists, err)
+	}
+
+	err = s3def.Move("src.txt", "dst.txt")
+	if !errors.Is(err, gostorage.ErrNotExist) {
+		t.Errorf("S3.Move() error = %v, want %v", err, gostorage.ErrNotExist)
+	}
+}
+
+func Test_conditionalWrite(t *testing.T) {
+	tests := []struct {
+		name        string
+		operation   func(svc *s3.S3) *request.Request
+		opts        gostorage.WriteOptions
+		wantHeaders map[string]string
+	}{
+		{
+			name: "put if not exists",
+			operation: func(svc *s3.S3) *request.Request {
+				req, _ := svc.PutObjectRequest(&s3.PutObjectInput{})
+				return req
+			},
+			opts:        gostorage.WriteOptions{IfNotExists: true},
+			wantHeaders: map[string]string{"If-None-Match": "*", "If-Match": ""},
+		},
+		{
+			name: "complete if match",
+			operation: func(svc *s3.S3) *request.Request {
+				req, _ := svc.CompleteMultipartUploadRequest(&s3.CompleteMultipartUploadInput{})
+				return req
+			},
+			opts:        gostorage.WriteOptions{IfMatch: "abc"},
+			wantHeaders: map[string]string{"If-None-Match": "", "If-Match": `"abc"`},
+		},
+		{
+			name: "upload part",
+			operation: func(svc *s3.S3) *request.Request {
+				req, _ := svc.UploadPartRequest(&s3.UploadPartInput{})
+				return req
+			},
+			opts:        gostorage.WriteOptions{IfMatch: "abc"},
+			wantHeaders: map[string]string{"If-None-Match": "", "If-Match": ""},
+		},
+		{
+			name: "unconditional",
+			operation: func(svc *s3.S3) *request.Request {
+				req, _ := svc.PutObjectRequest(&s3.PutObjectInput{})
+				return req
+			},
+			opts:        gostorage.WriteOptions{},
+			wantHeaders: map[string]string{"If-None-Match": "", "If-Match": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.operation(s3.New(awsSession))
+			req.ApplyOptions(conditionalWrite(tt.opts))
+			for header, want := range tt.wantHeaders {
+				if got := req.HTTPRequest.Header.Get(header); got != want {
+					t.Errorf("conditionalWrite() header %s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestS3_DeleteMany_batches(t *testing.T) {
+	svc := s3.New(awsSession)
+	keys := []string{}
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("batch-%d.txt", i)
+		keys = append(keys, key)
+		_, err := svc.PutObject(&s3.PutObjectInput{
+			Bucket: aws.String(testBucket),
+			Key:    aws.String(key),
+			Body:   strings.NewReader("test"),
+		})
+		if err != nil {
+			t.Errorf("PutObject() error = %v", err)
+			return
+		}
+	}
+
+	defaultMaxDeleteObjects := maxDeleteObjects
+	maxDeleteObjects = 2
+	defer func() {
+		maxDeleteObjects = defaultMaxDeleteObjects
+	}()
+
+	s3def := S3{
+		Bucket:  testBucket,
+		conn:    svc,
+		session: awsSession,
+	}
+	errs := s3def.DeleteMany(keys)
+	if len(errs) != 0 {
+		t.Errorf("DeleteMany() = %v, want no errors", errs)
+	}
+	for _, key := range keys {
+		exists, err := s3def.Exists(key)
+		if err != nil {
+			t.Errorf("Exists() error = %v", err)
+			continue
+		}
+		if exists {
+			t.Errorf("DeleteMany() did not delete %q", key)
+		}
+	}
+}
+
+func TestS3_SignURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		wantErr bool
+	}{
+		{
+			name:    "get",
+			method:  http.MethodGet,
+			wantErr: false,
+		},
+		{
+			name:    "put",
+			method:  http.MethodPut,
+			wantErr: false,
+		},
+		{
+			name:    "unsupported method",
+			method:  http.MethodDelete,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3def := S3{
+				Bucket:  testBucket,
+				conn:    s3.New(awsSession),
+				session: awsSession,
+			}
+			got, err := s3def.SignURL(tt.method, "dir/test.txt", 15*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("S3.SignURL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !errors.Is(err, gostorage.ErrNotSupported) {
+					t.Errorf("S3.SignURL() error = %v, want %v", err, gostorage.ErrNotSupported)
+				}
+				return
+			}
+			u, err := url.Parse(got)
+			if err != nil {
+				t.Errorf("url.Parse() error = %v", err)
+				return
+			}
+			if !strings.HasSuffix(u.Path, "/dir/test.txt") {
+				t.Errorf("S3.SignURL() path = %v, want suffix %v", u.Path, "/dir/test.txt")
+			}
+			if expires := u.Query().Get("X-Amz-Expires"); expires != "900" {
+				t.Errorf("S3.SignURL() X-Amz-Expires = %v, want %v", expires, "900")
+			}
+			if u.Query().Get("X-Amz-Signature") == "" {
+				t.Errorf("S3.SignURL() X-Amz-Signature is missing")
+			}
+		})
+	}
+}
+
+func TestS3_SignURL_roundTrip(t *testing.T) {
+	s3def := S3{
+		Bucket:  testBucket,
+		conn:    s3.New(awsSession),
+		session: awsSession,
+	}
+	putURL, err := s3def.SignURL(http.MethodPut, "signed.txt", time.Minute)
+	if err != nil {
+		t.Errorf("S3.SignURL() error = %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPut, putURL, strings.NewReader("uploaded"))
+	if err != nil {
+		t.Errorf("http.NewRequest() error = %v", err)
+		return
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("PUT error = %v", err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("PUT status = %v, want %v", res.StatusCode, http.StatusOK)
+		return
+	}
+	defer s3def.Delete("signed.txt")
+
+	getURL, err := s3def.SignURL(http.MethodGet, "signed.txt", time.Minute)
+	if err != nil {
+		t.Errorf("S3.SignURL() error = %v", err)
+		return
+	}
+	res, err = http.Get(getURL)
+	if err != nil {
+		t.Errorf("GET error = %v", err)
+		return
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Errorf("ReadAll() error = %v", err)
+		return
+	}
+	if string(body) != "uploaded" {
+		t.Errorf("GET body = %q, want %q", body, "uploaded")
+	}
+}
+
+func TestS3_objectKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathPrefix string
+		key        string
+		want       string
+	}{
+		{
+			name:       "no path prefix",
+			pathPrefix: "",
+			key:        "dir/test.txt",
+			want:       "dir/test.txt",
+		},
+		{
+			name:       "path prefix",
+			pathPrefix: "app",
+			key:        "dir/test.txt",
+			want:       "app/dir/test.txt",
+		},
+		{
+			name:       "path prefix with slashes",
+			pathPrefix: "/app/",
+			key:        "dir/test.txt",
+			want:       "app/dir/test.txt",
+		},
+		{
+			name:       "nested path prefix",
+			pathPrefix: "apps/app",
+			key:        "test.txt",
+			want:       "apps/app/test.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3def := S3{
+				Bucket:     testBucket,
+				PathPrefix: tt.pathPrefix,
+			}
+			got := s3def.objectKey(tt.key)
+			if got != tt.want {
+				t.Errorf("S3.objectKey() = %v, want %v", got, tt.want)
+			}
+			if key := s3def.trimKey(got); key != tt.key {
+				t.Errorf("S3.trimKey() = %v, want %v", key, tt.key)
+			}
+		})
+	}
+}
+
+func TestS3_PathPrefix(t *testing.T) {
+	svc := s3.New(awsSession)
+	app := NewS3(testBucket, "app", svc, awsSession)
+	other := NewS3(testBucket, "app2", svc, awsSession)
+	defer func() {
+		for _, key := range []string{"app/test.txt", "app/copy.txt", "app2/test.txt"} {
+			_, err := svc.DeleteObject(&s3.DeleteObjectInput{
+				Bucket: aws.String(testBucket),
+				Key:    aws.String(key),
+			})
+			if err != nil {
+				t.Errorf("DeleteObject() error = %v", err)
+			}
+		}
+	}()
+
+	for _, d := range []*S3{app, other} {
+		err := d.Write("test.txt", strings.NewReader(d.PathPrefix))
+		if err != nil {
+			t.Errorf("S3.Write() error = %v", err)
+			return
+		}
+	}
+	for _, d := range []*S3{app, other} {
+		got, err := d.Read("test.txt")
+		if err != nil {
+			t.Errorf("S3.Read() error = %v", err)
+			return
+		}
+		bts, _ := ioutil.ReadAll(got)
+		if string(bts) != d.PathPrefix {
+			t.Errorf("S3.Read() = %q, want %q", bts, d.PathPrefix)
+		}
+	}
+	_, err := svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(testBucket),
+		Key:    aws.String("app/test.txt"),
+	})
+	if err != nil {
+		t.Errorf("HeadObject() error = %v, want the object below the path prefix", err)
+	}
+
+	err = app.Copy("test.txt", "copy.txt")
+	if err != nil {
+		t.Errorf("S3.Copy() error = %v", err)
+	}
+	keys, err := app.List()
+	if err != nil {
+		t.Errorf("S3.List() error = %v", err)
+	}
+	if want := []string{"copy.txt", "test.txt"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("S3.List() = %v, want %v", keys, want)
+	}
+	info, err := app.Stat("copy.txt")
+	if err != nil {
+		t.Errorf("S3.Stat() error = %v", err)
+	} else if info.Key != "copy.txt" {
+		t.Errorf("S3.Stat() key = %v, want %v", info.Key, "copy.txt")
+	}
+	signedURL, err := app.SignURL(http.MethodGet, "copy.txt", time.Minute)
+	if err != nil {
+		t.Errorf("S3.SignURL() error = %v", err)
+	} else if !strings.Contains(signedURL, "/app/copy.txt?") {
+		t.Errorf("S3.SignURL() = %v, want the key below the path prefix", signedURL)
+	}
+
+	errs := app.DeleteMany([]string{"test.txt", "copy.txt"})
+	if len(errs) != 0 {
+		t.Errorf("S3.DeleteMany() = %v, want no errors", errs)
+	}
+	exists, err := other.Exists("test.txt")
+	if err != nil || !exists {
+		t.Errorf("S3.Exists() = %v, %v, want the object of the other path prefix to exist", exists, err)
+	}
+}
+
+func TestS3_Capabilities_versioning(t *testing.T) {
+	svc := s3.New(awsSession)
+	s3def := NewS3(testBucket, "", svc, awsSession)
+	if s3def.Capabilities().Has(gostorage.CapabilityVersioning) {
+		t.Errorf("S3.Capabilities() = %v, want no %v for a bucket without versioning", s3def.Capabilities(), gostorage.CapabilityVersioning)
+	}
+	if s3def.HasCapability(gostorage.CapabilityVersioning) {
+		t.Errorf("S3.HasCapability() = true, want false for %v of a bucket without versioning", gostorage.CapabilityVersioning)
+	}
+
+	// the failed request for a missing bucket is retried by the next call
+	missing := S3{Bucket: "missing-bucket", conn: svc}
+	if missing.Capabilities().Has(gostorage.CapabilityVersioning) {
+		t.Errorf("S3.Capabilities() = %v, want no %v for a missing bucket", missing.Capabilities(), gostorage.CapabilityVersioning)
+	}
+	if _, ok := bucketVersioning.Load(bucketRef{conn: svc, bucket: "missing-bucket"}); ok {
+		t.Errorf("S3.Capabilities() cached the status of a missing bucket")
+	}
+	if !missing.HasCapability(gostorage.CapabilityConditionalWrite) {
+		t.Errorf("S3.HasCapability() = false, want true for %v", gostorage.CapabilityConditionalWrite)
+	}
+}
@@ -0,0 +1,89 @@
+package gostorage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+var (
+	// ErrNotExist is returned if a file/object does not exist.
+	// It is the same error as fs.ErrNotExist, so that errors.Is(err, os.ErrNotExist) works as well.
+	ErrNotExist = fs.ErrNotExist
+	// ErrExist is returned if a file/object already exists.
+	// It is the same error as fs.ErrExist.
+	ErrExist = fs.ErrExist
+	// ErrPermission is returned if the access to a file/object is denied.
+	// It is the same error as fs.ErrPermission.
+	ErrPermission = fs.ErrPermission
+	// ErrInvalidKey is returned if a key is not valid for the driver.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrInvalidRange is returned if a byte range can not be satisfied, e.g. because
+	// it starts beyond the end of the content.
+	ErrInvalidRange = errors.New("invalid range")
+	// ErrPreconditionFailed is returned if a condition of a conditional operation is not met.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrNotSupported is returned if a driver does not support an operation
+	// and there is no generic implementation to fall back to.
+	ErrNotSupported = errors.New("not supported")
+)
+
+// StorageError records an error and the operation, key and driver that caused it.
+// The drivers map their native errors onto the sentinel errors of this package,
+// so that errors.Is can be used independent of the driver.
+type StorageError struct {
+	// Op is the operation that failed, e.g. "read".
+	Op string
+	// Key is the key of the file/object. It is empty for operations that are not
+	// bound to a single file/object.
+	Key string
+	// Driver is the name of the driver, e.g. "s3".
+	Driver string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *StorageError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %s: %v", e.Driver, e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s: %s %q: %v", e.Driver, e.Op, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
+// DeleteError records the errors of the files/objects a bulk delete could not delete.
+type DeleteError struct {
+	// Errors contains the error of every key that could not be deleted.
+	Errors map[string]error
+}
+
+func (e *DeleteError) Error() string {
+	key := e.firstKey()
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("%q could not be deleted: %v", key, e.Errors[key])
+	}
+	return fmt.Sprintf("%d keys could not be deleted, first %q: %v", len(e.Errors), key, e.Errors[key])
+}
+
+// Unwrap returns the error of the lexically first key that could not be deleted.
+func (e *DeleteError) Unwrap() error {
+	return e.Errors[e.firstKey()]
+}
+
+// firstKey returns the lexically first key that could not be deleted.
+func (e *DeleteError) firstKey() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0]
+}
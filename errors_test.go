@@ -0,0 +1,101 @@
+package gostorage
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestStorageError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *StorageError
+		want string
+	}{
+		{
+			name: "with key",
+			err: &StorageError{
+				Op:     "read",
+				Key:    "test.txt",
+				Driver: "s3",
+				Err:    ErrNotExist,
+			},
+			want: `s3: read "test.txt": file does not exist`,
+		},
+		{
+			name: "without key",
+			err: &StorageError{
+				Op:     "list",
+				Driver: "local-storage",
+				Err:    ErrPermission,
+			},
+			want: "local-storage: list: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("StorageError.Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorageError_Unwrap(t *testing.T) {
+	var err error = &StorageError{
+		Op:     "read",
+		Key:    "test.txt",
+		Driver: "local-storage",
+		Err:    &os.PathError{Op: "open", Path: "/tmp/test/test.txt", Err: os.ErrNotExist},
+	}
+	if !errors.Is(err, ErrNotExist) {
+		t.Errorf("errors.Is(%v, ErrNotExist) = false, want true", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("errors.Is(%v, os.ErrNotExist) = false, want true", err)
+	}
+	if errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("errors.Is(%v, ErrPreconditionFailed) = true, want false", err)
+	}
+	var pathErr *os.PathError
+	if !errors.As(err, &pathErr) {
+		t.Errorf("errors.As(%v, *os.PathError) = false, want true", err)
+	}
+}
+
+func TestDeleteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        *DeleteError
+		want       string
+		wantUnwrap error
+	}{
+		{
+			name: "single key",
+			err: &DeleteError{Errors: map[string]error{
+				"a.txt": ErrPermission,
+			}},
+			want:       `"a.txt" could not be deleted: permission denied`,
+			wantUnwrap: ErrPermission,
+		},
+		{
+			name: "multiple keys",
+			err: &DeleteError{Errors: map[string]error{
+				"b.txt": ErrNotExist,
+				"a.txt": ErrPermission,
+			}},
+			want:       `2 keys could not be deleted, first "a.txt": permission denied`,
+			wantUnwrap: ErrPermission,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("DeleteError.Error() = %v, want %v", got, tt.want)
+			}
+			if got := tt.err.Unwrap(); got != tt.wantUnwrap {
+				t.Errorf("DeleteError.Unwrap() = %v, want %v", got, tt.wantUnwrap)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package gostorage
+package gostorage_test
 
 import (
 	"errors"
@@ -13,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	gostorage "github.com/leonsteinhaeuser/go-storage-abstraction"
 	"github.com/leonsteinhaeuser/go-storage-abstraction/drivers"
 	"github.com/orlangure/gnomock"
 	"github.com/orlangure/gnomock/preset/localstack"
@@ -22,8 +23,8 @@ var (
 	config     *aws.Config
 	awsSession *session.Session
 
-	s3StorageDriver    Driver
-	localStorageDriver Driver
+	s3StorageDriver    gostorage.Driver
+	localStorageDriver gostorage.Driver
 )
 
 func TestMain(m *testing.M) {
@@ -0,0 +1,334 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FS exposes a driver as file system. The keys of the driver are split at "/"
+// into directories and files, so that "a/b.txt" is the file b.txt within the
+// directory a. A directory exists as long as it contains at least one file.
+//
+// FS implements fs.FS, fs.StatFS, fs.ReadDirFS and fs.ReadFileFS. The opened files
+// implement io.Seeker and io.ReaderAt, so that FS can be used with http.FS.
+type FS struct {
+	driver Driver
+}
+
+// NewFS returns the file system of the driver.
+func NewFS(d Driver) *FS {
+	return &FS{driver: d}
+}
+
+// Open opens the named file or directory.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &fsDir{fsys: f, name: name, info: info}, nil
+	}
+	return &fsFile{
+		fsys:    f,
+		key:     name,
+		info:    info,
+		content: newRangeReadSeeker(context.Background(), f.driver, name, info.Size()),
+	}, nil
+}
+
+// Stat returns the fs.FileInfo of the named file or directory.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	return f.stat("stat", name)
+}
+
+// ReadDir reads the named directory and returns its entries sorted by name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if len(entries) == 0 && name != "." {
+		// the name is either a file or does not exist at all
+		info, err := f.stat("readdir", name)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+		}
+	}
+	return entries, nil
+}
+
+// ReadFile reads the named file and returns its content.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	rc, err := ReadStream(context.Background(), f.driver, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	defer rc.Close()
+	bts, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	return bts, nil
+}
+
+// stat returns the fs.FileInfo of the named file or directory.
+// Errors are reported as *fs.PathError of the operation op.
+func (f *FS) stat(op, name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return fsDirInfo{name: "."}, nil
+	}
+	info, err := Stat(context.Background(), f.driver, name)
+	if err == nil {
+		return newFSFileInfo(*info), nil
+	}
+	if !errors.Is(err, ErrNotExist) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	// the name is a directory if there is any file below it
+	res, lerr := ListPage(context.Background(), f.driver, ListOptions{Prefix: name + "/", PageSize: 1})
+	if lerr != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: lerr}
+	}
+	if len(res.Objects) == 0 && len(res.CommonPrefixes) == 0 {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return fsDirInfo{name: path.Base(name)}, nil
+}
+
+// readDir returns the entries of the named directory sorted by name.
+func (f *FS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+	// the fallback of ListPage only returns the keys, so the size and modification
+	// time of the files are determined by Stat once they are requested
+	_, isLister := f.driver.(Lister)
+	entries := []fs.DirEntry{}
+	opts := ListOptions{Prefix: prefix, Delimiter: "/"}
+	for {
+		res, err := ListPage(context.Background(), f.driver, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range res.Objects {
+			// keys that are no valid paths, like "a//b.txt", can not be opened
+			if !fs.ValidPath(o.Key) {
+				continue
+			}
+			if !isLister {
+				entries = append(entries, fsFileEntry{fsys: f, key: o.Key})
+				continue
+			}
+			entries = append(entries, fs.FileInfoToDirEntry(newFSFileInfo(o)))
+		}
+		for _, cp := range res.CommonPrefixes {
+			dirName := strings.TrimSuffix(strings.TrimPrefix(cp, prefix), "/")
+			if dirName == "" {
+				continue
+			}
+			entries = append(entries, fs.FileInfoToDirEntry(fsDirInfo{name: dirName}))
+		}
+		if res.NextContinuationToken == "" {
+			break
+		}
+		opts.ContinuationToken = res.NextContinuationToken
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// fsFileInfo describes a file of FS.
+type fsFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// newFSFileInfo returns the fs.FileInfo of the file/object. The modification time is
+// truncated to seconds, as S3 returns it in different precisions by listing and stat.
+func newFSFileInfo(info ObjectInfo) fsFileInfo {
+	return fsFileInfo{
+		name:    path.Base(info.Key),
+		size:    info.Size,
+		modTime: info.LastModified.Truncate(time.Second),
+	}
+}
+
+func (i fsFileInfo) Name() string       { return i.name }
+func (i fsFileInfo) Size() int64        { return i.size }
+func (i fsFileInfo) Mode() fs.FileMode  { return 0444 }
+func (i fsFileInfo) ModTime() time.Time { return i.modTime }
+func (i fsFileInfo) IsDir() bool        { return false }
+func (i fsFileInfo) Sys() interface{}   { return nil }
+
+// fsFileEntry is the directory entry of a file whose fs.FileInfo is determined on request.
+type fsFileEntry struct {
+	fsys *FS
+	key  string
+}
+
+func (e fsFileEntry) Name() string               { return path.Base(e.key) }
+func (e fsFileEntry) IsDir() bool                { return false }
+func (e fsFileEntry) Type() fs.FileMode          { return 0 }
+func (e fsFileEntry) Info() (fs.FileInfo, error) { return e.fsys.stat("stat", e.key) }
+
+// fsDirInfo describes a directory of FS.
+type fsDirInfo struct {
+	name string
+}
+
+func (i fsDirInfo) Name() string       { return i.name }
+func (i fsDirInfo) Size() int64        { return 0 }
+func (i fsDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (i fsDirInfo) ModTime() time.Time { return time.Time{} }
+func (i fsDirInfo) IsDir() bool        { return true }
+func (i fsDirInfo) Sys() interface{}   { return nil }
+
+// fsFile is a file of FS. The content is streamed from the current offset
+// when it is read sequentially and read by ranges when it is read by ReadAt.
+type fsFile struct {
+	fsys     *FS
+	key      string
+	info     fs.FileInfo
+	content  *rangeReadSeeker
+	readerAt ReadAtCloser
+	closed   bool
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	if f.closed {
+		return nil, &fs.PathError{Op: "stat", Path: f.key, Err: fs.ErrClosed}
+	}
+	return f.info, nil
+}
+
+func (f *fsFile) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.key, Err: fs.ErrClosed}
+	}
+	n, err := f.content.Read(p)
+	if err != nil && err != io.EOF {
+		return n, &fs.PathError{Op: "read", Path: f.key, Err: err}
+	}
+	return n, err
+}
+
+func (f *fsFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.key, Err: fs.ErrClosed}
+	}
+	offset, err := f.content.Seek(offset, whence)
+	if err != nil {
+		return 0, &fs.PathError{Op: "seek", Path: f.key, Err: err}
+	}
+	return offset, nil
+}
+
+func (f *fsFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.key, Err: fs.ErrClosed}
+	}
+	if f.readerAt == nil {
+		ra, err := OpenReaderAt(context.Background(), f.fsys.driver, f.key)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.key, Err: err}
+		}
+		f.readerAt = ra
+	}
+	return f.readerAt.ReadAt(p, off)
+}
+
+func (f *fsFile) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.key, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	err := f.content.Close()
+	if f.readerAt != nil {
+		if cerr := f.readerAt.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// fsDir is a directory of FS.
+type fsDir struct {
+	fsys    *FS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	read    bool
+	closed  bool
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "stat", Path: d.name, Err: fs.ErrClosed}
+	}
+	return d.info, nil
+}
+
+func (d *fsDir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir returns the next n entries of the directory, or all remaining entries if n <= 0.
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: fs.ErrClosed}
+	}
+	if !d.read {
+		entries, err := d.fsys.readDir(d.name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: err}
+		}
+		d.entries = entries
+		d.read = true
+	}
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+func (d *fsDir) Close() error {
+	if d.closed {
+		return &fs.PathError{Op: "close", Path: d.name, Err: fs.ErrClosed}
+	}
+	d.closed = true
+	return nil
+}
@@ -0,0 +1,91 @@
+package gostorage
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	d := memoryDriver{
+		"a.txt":         []byte("a"),
+		"dir/b.txt":     []byte("b"),
+		"dir/sub/c.txt": []byte("c"),
+		"dir2/d.txt":    []byte("d"),
+	}
+	err := fstest.TestFS(NewFS(d), "a.txt", "dir/b.txt", "dir/sub/c.txt", "dir2/d.txt")
+	if err != nil {
+		t.Errorf("fstest.TestFS() error = %v", err)
+	}
+}
+
+func TestFS_errors(t *testing.T) {
+	fsys := NewFS(memoryDriver{
+		"a.txt":     []byte("a"),
+		"dir/b.txt": []byte("b"),
+	})
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{
+			name: "open missing",
+			call: func() error {
+				_, err := fsys.Open("missing.txt")
+				return err
+			},
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name: "open invalid path",
+			call: func() error {
+				_, err := fsys.Open("/a.txt")
+				return err
+			},
+			wantErr: fs.ErrInvalid,
+		},
+		{
+			name: "read dir of missing",
+			call: func() error {
+				_, err := fsys.ReadDir("missing")
+				return err
+			},
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name: "read file of missing",
+			call: func() error {
+				_, err := fsys.ReadFile("dir/missing.txt")
+				return err
+			},
+			wantErr: fs.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			var perr *fs.PathError
+			if !errors.As(err, &perr) {
+				t.Errorf("error = %T, want %T", err, perr)
+			}
+		})
+	}
+
+	_, err := fsys.ReadDir("a.txt")
+	if err == nil {
+		t.Errorf("ReadDir() of a file error = nil, want an error")
+	}
+	got, err := fs.Glob(fsys, "*/*.txt")
+	if err != nil {
+		t.Errorf("fs.Glob() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"dir/b.txt"}) {
+		t.Errorf("fs.Glob() = %v, want %v", got, []string{"dir/b.txt"})
+	}
+}
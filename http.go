@@ -0,0 +1,91 @@
+package gostorage
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
+)
+
+// Handler serves the files/objects of a driver over HTTP. The key of the file/object
+// is the path of the request URL without the leading slash, so http.StripPrefix
+// can be used to serve the driver below a path.
+//
+// GET and HEAD requests are supported, including byte ranges and conditional requests
+// by ETag and modification time as implemented by http.ServeContent.
+type Handler struct {
+	driver Driver
+}
+
+// NewHandler returns the handler serving the files/objects of the driver.
+func NewHandler(d Driver) *Handler {
+	return &Handler{driver: d}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	info, err := Stat(r.Context(), h.driver, key)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	// the content is streamed from the requested offset by a single request,
+	// independent of the size of the chunks copied by http.ServeContent
+	content := newRangeReadSeeker(r.Context(), h.driver, key, info.Size)
+	defer content.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType, err = utils.MimeType(content)
+		if err == nil {
+			_, err = content.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			httpError(w, err)
+			return
+		}
+	}
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	if info.ETag != "" {
+		header.Set("ETag", `"`+info.ETag+`"`)
+	}
+	if info.CacheControl != "" {
+		header.Set("Cache-Control", info.CacheControl)
+	}
+	if info.ContentDisposition != "" {
+		header.Set("Content-Disposition", info.ContentDisposition)
+	}
+	if info.ContentEncoding != "" {
+		header.Set("Content-Encoding", info.ContentEncoding)
+	}
+	http.ServeContent(w, r, path.Base(key), info.LastModified, content)
+}
+
+// httpError replies with the status code matching the error of the driver.
+// The error itself is not exposed to the client.
+func httpError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotExist):
+		code = http.StatusNotFound
+	case errors.Is(err, ErrPermission):
+		code = http.StatusForbidden
+	case errors.Is(err, ErrInvalidKey):
+		code = http.StatusBadRequest
+	}
+	http.Error(w, http.StatusText(code), code)
+}
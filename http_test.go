@@ -0,0 +1,278 @@
+package gostorage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// statDriver is a memoryDriver implementing Stater with a fixed modification time.
+type statDriver struct {
+	memoryDriver
+	modTime time.Time
+}
+
+func (s statDriver) Stat(key string) (*ObjectInfo, error) {
+	return s.StatContext(context.Background(), key)
+}
+
+func (s statDriver) StatContext(ctx context.Context, key string) (*ObjectInfo, error) {
+	info, err := Stat(ctx, s.memoryDriver, key)
+	if err != nil {
+		return nil, err
+	}
+	info.LastModified = s.modTime
+	info.CacheControl = "max-age=60"
+	return info, nil
+}
+
+func TestHandler(t *testing.T) {
+	modTime := time.Date(2021, 12, 24, 12, 0, 0, 0, time.UTC)
+	etag := `"781e5e245d69b566979b86e28d23f2c7"`
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		header      map[string]string
+		wantStatus  int
+		wantBody    string
+		wantHeaders map[string]string
+	}{
+		{
+			name:       "get",
+			method:     http.MethodGet,
+			path:       "/dir/test.txt",
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+			wantHeaders: map[string]string{
+				"Content-Type":   "text/plain; charset=utf-8",
+				"Content-Length": "10",
+				"ETag":           etag,
+				"Last-Modified":  "Fri, 24 Dec 2021 12:00:00 GMT",
+				"Cache-Control":  "max-age=60",
+				"Accept-Ranges":  "bytes",
+			},
+		},
+		{
+			name:       "head",
+			method:     http.MethodHead,
+			path:       "/dir/test.txt",
+			wantStatus: http.StatusOK,
+			wantBody:   "",
+			wantHeaders: map[string]string{
+				"Content-Type":   "text/plain; charset=utf-8",
+				"Content-Length": "10",
+				"ETag":           etag,
+			},
+		},
+		{
+			name:       "range",
+			method:     http.MethodGet,
+			path:       "/dir/test.txt",
+			header:     map[string]string{"Range": "bytes=2-4"},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   "234",
+			wantHeaders: map[string]string{
+				"Content-Range":  "bytes 2-4/10",
+				"Content-Length": "3",
+			},
+		},
+		{
+			name:       "suffix range",
+			method:     http.MethodGet,
+			path:       "/dir/test.txt",
+			header:     map[string]string{"Range": "bytes=-3"},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   "789",
+		},
+		{
+			name:       "unsatisfiable range",
+			method:     http.MethodGet,
+			path:       "/dir/test.txt",
+			header:     map[string]string{"Range": "bytes=20-"},
+			wantStatus: http.StatusRequestedRangeNotSatisfiable,
+		},
+		{
+			name:       "if none match",
+			method:     http.MethodGet,
+			path:       "/dir/test.txt",
+			header:     map[string]string{"If-None-Match": etag},
+			wantStatus: http.StatusNotModified,
+			wantBody:   "",
+		},
+		{
+			name:       "if none match changed",
+			method:     http.MethodGet,
+			path:       "/dir/test.txt",
+			header:     map[string]string{"If-None-Match": `"other"`},
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+		},
+		{
+			name:       "if modified since",
+			method:     http.MethodGet,
+			path:       "/dir/test.txt",
+			header:     map[string]string{"If-Modified-Since": "Sat, 25 Dec 2021 12:00:00 GMT"},
+			wantStatus: http.StatusNotModified,
+			wantBody:   "",
+		},
+		{
+			name:       "if range",
+			method:     http.MethodGet,
+			path:       "/dir/test.txt",
+			header:     map[string]string{"Range": "bytes=0-1", "If-Range": `"other"`},
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+		},
+		{
+			name:       "not found",
+			method:     http.MethodGet,
+			path:       "/missing.txt",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "directory",
+			method:     http.MethodGet,
+			path:       "/dir/",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodPost,
+			path:       "/dir/test.txt",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantHeaders: map[string]string{
+				"Allow": "GET, HEAD",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(statDriver{
+				memoryDriver: memoryDriver{"dir/test.txt": []byte("0123456789")},
+				modTime:      modTime,
+			})
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v", res.StatusCode, tt.wantStatus)
+			}
+			for k, v := range tt.wantHeaders {
+				if got := res.Header.Get(k); got != v {
+					t.Errorf("ServeHTTP() header %s = %q, want %q", k, got, v)
+				}
+			}
+			if tt.wantStatus >= 400 {
+				return
+			}
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Errorf("ReadAll() error = %v", err)
+				return
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("ServeHTTP() body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// countingDriver is a statDriver implementing StreamReader and RangeReader
+// that counts the calls of the driver.
+type countingDriver struct {
+	statDriver
+	calls map[string]int
+}
+
+func (c countingDriver) StatContext(ctx context.Context, key string) (*ObjectInfo, error) {
+	c.calls["stat"]++
+	return c.statDriver.StatContext(ctx, key)
+}
+
+func (c countingDriver) ReadStream(key string) (io.ReadCloser, error) {
+	return c.ReadStreamContext(context.Background(), key)
+}
+
+func (c countingDriver) ReadStreamContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	c.calls["read stream"]++
+	return ReadStream(ctx, c.memoryDriver, key)
+}
+
+func (c countingDriver) ReadRange(key string, offset, length int64) (io.ReadCloser, error) {
+	return c.ReadRangeContext(context.Background(), key, offset, length)
+}
+
+func (c countingDriver) ReadRangeContext(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	c.calls["read range"]++
+	return ReadRange(ctx, c.memoryDriver, key, offset, length)
+}
+
+func (c countingDriver) OpenReaderAt(key string) (ReadAtCloser, error) {
+	return c.OpenReaderAtContext(context.Background(), key)
+}
+
+func (c countingDriver) OpenReaderAtContext(ctx context.Context, key string) (ReadAtCloser, error) {
+	c.calls["open reader at"]++
+	return OpenReaderAt(ctx, c.memoryDriver, key)
+}
+
+func TestHandler_driverCalls(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1<<20)
+	tests := []struct {
+		name      string
+		header    map[string]string
+		wantBody  []byte
+		wantCalls map[string]int
+	}{
+		{
+			name:      "whole content",
+			wantBody:  content,
+			wantCalls: map[string]int{"stat": 1, "read stream": 1},
+		},
+		{
+			name:      "range",
+			header:    map[string]string{"Range": "bytes=1000-"},
+			wantBody:  content[1000:],
+			wantCalls: map[string]int{"stat": 1, "read range": 1},
+		},
+		{
+			name:      "not modified",
+			header:    map[string]string{"If-None-Match": "*"},
+			wantCalls: map[string]int{"stat": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := countingDriver{
+				statDriver: statDriver{memoryDriver: memoryDriver{"video.mp4": content}},
+				calls:      map[string]int{},
+			}
+			req := httptest.NewRequest(http.MethodGet, "/video.mp4", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			NewHandler(d).ServeHTTP(rec, req)
+
+			if !bytes.Equal(rec.Body.Bytes(), tt.wantBody) {
+				t.Errorf("ServeHTTP() returned %d bytes, want %d bytes", rec.Body.Len(), len(tt.wantBody))
+			}
+			if !reflect.DeepEqual(d.calls, tt.wantCalls) {
+				t.Errorf("ServeHTTP() driver calls = %v, want %v", d.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package gostorage
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// DefaultPageSize is the number of keys returned by Lister
+// if ListOptions.PageSize is not set.
+const DefaultPageSize = 1000
+
+// ListOptions defines which files/objects are listed by Lister.
+type ListOptions struct {
+	// Prefix limits the listing to keys that start with the prefix.
+	Prefix string
+	// StartAfter limits the listing to keys that are lexically greater than StartAfter.
+	StartAfter string
+	// ContinuationToken continues a previous listing. It must be taken from
+	// ListResult.NextContinuationToken of the previous page and takes precedence over StartAfter.
+	ContinuationToken string
+	// Delimiter groups the keys that contain the delimiter after the prefix.
+	// Instead of the keys, the common prefix up to and including the first
+	// occurrence of the delimiter is returned in ListResult.CommonPrefixes.
+	// Using "/" lists the files/objects hierarchically like directories.
+	Delimiter string
+	// PageSize is the maximum number of keys and common prefixes returned per page.
+	// If it is not set, DefaultPageSize is used. Drivers may return fewer keys
+	// than requested, e.g. S3 returns at most 1000 keys per page.
+	PageSize int
+}
+
+// ListResult is a single page of a listing.
+type ListResult struct {
+	// Objects contains the files/objects of the page in lexical order of their keys.
+	// Only Key, Size and LastModified are guaranteed to be set.
+	Objects []ObjectInfo
+	// CommonPrefixes contains the common prefixes of the keys grouped by ListOptions.Delimiter
+	// in lexical order. Every common prefix contains at least one file/object.
+	CommonPrefixes []string
+	// NextContinuationToken is the opaque token to request the next page with.
+	// It is empty if the page is the last one.
+	NextContinuationToken string
+}
+
+// ListPage returns a single page of the listing selected by opts.
+// If the driver implements Lister, the page is listed by the driver.
+// Otherwise all keys are listed by List and the page is selected from them.
+func ListPage(ctx context.Context, d Driver, opts ListOptions) (*ListResult, error) {
+	if l, ok := d.(Lister); ok {
+		return l.ListPageContext(ctx, opts)
+	}
+	keys, err := AsDriverContext(d).ListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	startAfter := opts.StartAfter
+	if opts.ContinuationToken != "" {
+		startAfter = opts.ContinuationToken
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	res := &ListResult{}
+	// last is the greatest key or common prefix of the page
+	last := ""
+	count := 0
+	for _, key := range keys {
+		if !strings.HasPrefix(key, opts.Prefix) {
+			continue
+		}
+		entry, isPrefix := key, false
+		if opts.Delimiter != "" {
+			if i := strings.Index(key[len(opts.Prefix):], opts.Delimiter); i >= 0 {
+				entry, isPrefix = key[:len(opts.Prefix)+i+len(opts.Delimiter)], true
+			}
+		}
+		// a common prefix equal to startAfter has been returned by the previous page
+		if key <= startAfter || entry == startAfter || entry == last {
+			continue
+		}
+		if count == pageSize {
+			res.NextContinuationToken = last
+			break
+		}
+		if isPrefix {
+			res.CommonPrefixes = append(res.CommonPrefixes, entry)
+		} else {
+			res.Objects = append(res.Objects, ObjectInfo{Key: key})
+		}
+		last = entry
+		count++
+	}
+	return res, nil
+}
@@ -0,0 +1,103 @@
+package gostorage
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestListPage(t *testing.T) {
+	d := memoryDriver{
+		"a.txt":     []byte("a"),
+		"b/1.txt":   []byte("b"),
+		"b/2.txt":   []byte("b"),
+		"b/c/3.txt": []byte("b"),
+		"d.txt":     []byte("d"),
+	}
+	tests := []struct {
+		name  string
+		opts  ListOptions
+		want  []string
+		pages int
+	}{
+		{
+			name:  "all",
+			opts:  ListOptions{},
+			want:  []string{"a.txt", "b/1.txt", "b/2.txt", "b/c/3.txt", "d.txt"},
+			pages: 1,
+		},
+		{
+			name:  "paged",
+			opts:  ListOptions{PageSize: 2},
+			want:  []string{"a.txt", "b/1.txt", "b/2.txt", "b/c/3.txt", "d.txt"},
+			pages: 3,
+		},
+		{
+			name:  "prefix",
+			opts:  ListOptions{Prefix: "b/"},
+			want:  []string{"b/1.txt", "b/2.txt", "b/c/3.txt"},
+			pages: 1,
+		},
+		{
+			name:  "start after",
+			opts:  ListOptions{StartAfter: "b/2.txt"},
+			want:  []string{"b/c/3.txt", "d.txt"},
+			pages: 1,
+		},
+		{
+			name:  "delimiter",
+			opts:  ListOptions{Delimiter: "/"},
+			want:  []string{"a.txt", "b/", "d.txt"},
+			pages: 1,
+		},
+		{
+			name:  "delimiter paged",
+			opts:  ListOptions{Delimiter: "/", PageSize: 1},
+			want:  []string{"a.txt", "b/", "d.txt"},
+			pages: 3,
+		},
+		{
+			name:  "delimiter below prefix",
+			opts:  ListOptions{Prefix: "b/", Delimiter: "/"},
+			want:  []string{"b/1.txt", "b/2.txt", "b/c/"},
+			pages: 1,
+		},
+		{
+			name:  "delimiter start after within common prefix",
+			opts:  ListOptions{Delimiter: "/", StartAfter: "b/1.txt"},
+			want:  []string{"b/", "d.txt"},
+			pages: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			pages := 0
+			opts := tt.opts
+			for {
+				res, err := ListPage(context.Background(), d, opts)
+				if err != nil {
+					t.Errorf("ListPage() error = %v", err)
+					return
+				}
+				pages++
+				for _, o := range res.Objects {
+					got = append(got, o.Key)
+				}
+				got = append(got, res.CommonPrefixes...)
+				if res.NextContinuationToken == "" {
+					break
+				}
+				opts.ContinuationToken = res.NextContinuationToken
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListPage() = %v, want %v", got, tt.want)
+			}
+			if pages != tt.pages {
+				t.Errorf("ListPage() pages = %v, want %v", pages, tt.pages)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package gostorage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
+)
+
+// Upload identifies a multipart upload. It can be encoded as JSON to continue
+// the upload after a restart of the process.
+type Upload struct {
+	// Key is the key of the file/object that is uploaded.
+	Key string `json:"key"`
+	// UploadID identifies the upload within the driver.
+	UploadID string `json:"uploadId"`
+}
+
+// Part describes an uploaded part of a multipart upload.
+type Part struct {
+	// Number is the position of the part within the file/object, starting at 1.
+	Number int `json:"number"`
+	// ETag identifies the content of the part. It is never quoted.
+	ETag string `json:"etag"`
+	// Size is the size of the part in bytes.
+	Size int64 `json:"size"`
+}
+
+// UploadResumable uploads the size bytes of r in parts of partSize bytes and completes the upload.
+// Parts that have already been uploaded with the expected size and content, e.g. by a previous
+// process that has been interrupted, are not uploaded again. Therefore an interrupted upload is
+// continued by calling UploadResumable with the persisted upload and the same content again.
+// The content of an uploaded part is compared by its ETag, which must be the MD5 checksum of
+// the part like it is for the local storage and for S3 objects not encrypted by KMS. Parts
+// with any other ETag are uploaded again.
+//
+// Drivers may require a minimum part size, e.g. S3 requires all parts but the last one
+// to be at least 5 MiB. ErrNotSupported is returned if the driver does not implement
+// MultipartUploader.
+func UploadResumable(ctx context.Context, d Driver, upload *Upload, r io.ReaderAt, size, partSize int64) error {
+	mu, ok := d.(MultipartUploader)
+	if !ok {
+		return fmt.Errorf("%w: multipart uploads", ErrNotSupported)
+	}
+	if partSize <= 0 {
+		return fmt.Errorf("invalid part size %d", partSize)
+	}
+	uploaded, err := mu.ListPartsContext(ctx, upload)
+	if err != nil {
+		return err
+	}
+	done := make(map[int]Part, len(uploaded))
+	for _, part := range uploaded {
+		done[part.Number] = part
+	}
+
+	parts := []Part{}
+	for number, offset := 1, int64(0); offset < size || number == 1; number, offset = number+1, offset+partSize {
+		n := partSize
+		if offset+n > size {
+			n = size - offset
+		}
+		section := io.NewSectionReader(r, offset, n)
+		if part, ok := done[number]; ok && part.Size == n {
+			uploaded, err := partUploaded(ctx, part, section)
+			if err != nil {
+				return err
+			}
+			if uploaded {
+				parts = append(parts, part)
+				continue
+			}
+		}
+		part, err := mu.UploadPartContext(ctx, upload, number, section)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, *part)
+	}
+	return mu.CompleteUploadContext(ctx, upload, parts)
+}
+
+// partUploaded reports whether the uploaded part has the content of the section,
+// which is compared by the MD5 checksum. The section is rewound afterwards.
+func partUploaded(ctx context.Context, part Part, section *io.SectionReader) (bool, error) {
+	hash := md5.New()
+	_, err := io.Copy(hash, utils.ContextReader(ctx, section))
+	if err != nil {
+		return false, err
+	}
+	_, err = section.Seek(0, io.SeekStart)
+	if err != nil {
+		return false, err
+	}
+	return strings.Trim(part.ETag, `"`) == hex.EncodeToString(hash.Sum(nil)), nil
+}
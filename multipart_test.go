@@ -0,0 +1,169 @@
+package gostorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// multipartDriver is a memoryDriver that uploads a single file in parts.
+type multipartDriver struct {
+	memoryDriver
+	parts map[int][]byte
+	// uploaded contains the numbers of the parts uploaded by UploadPart.
+	uploaded []int
+}
+
+func (m *multipartDriver) InitiateUpload(key string, opts WriteOptions) (*Upload, error) {
+	return m.InitiateUploadContext(context.Background(), key, opts)
+}
+
+func (m *multipartDriver) InitiateUploadContext(ctx context.Context, key string, opts WriteOptions) (*Upload, error) {
+	m.parts = map[int][]byte{}
+	return &Upload{Key: key, UploadID: "upload"}, nil
+}
+
+func (m *multipartDriver) UploadPart(upload *Upload, number int, value io.Reader) (*Part, error) {
+	return m.UploadPartContext(context.Background(), upload, number, value)
+}
+
+func (m *multipartDriver) UploadPartContext(ctx context.Context, upload *Upload, number int, value io.Reader) (*Part, error) {
+	bts, err := ioutil.ReadAll(value)
+	if err != nil {
+		return nil, err
+	}
+	m.parts[number] = bts
+	m.uploaded = append(m.uploaded, number)
+	return m.part(number), nil
+}
+
+func (m *multipartDriver) part(number int) *Part {
+	sum := md5.Sum(m.parts[number])
+	return &Part{Number: number, ETag: hex.EncodeToString(sum[:]), Size: int64(len(m.parts[number]))}
+}
+
+func (m *multipartDriver) ListParts(upload *Upload) ([]Part, error) {
+	return m.ListPartsContext(context.Background(), upload)
+}
+
+func (m *multipartDriver) ListPartsContext(ctx context.Context, upload *Upload) ([]Part, error) {
+	parts := []Part{}
+	for number := range m.parts {
+		parts = append(parts, *m.part(number))
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+func (m *multipartDriver) CompleteUpload(upload *Upload, parts []Part) error {
+	return m.CompleteUploadContext(context.Background(), upload, parts)
+}
+
+func (m *multipartDriver) CompleteUploadContext(ctx context.Context, upload *Upload, parts []Part) error {
+	content := []byte{}
+	for _, part := range parts {
+		if !reflect.DeepEqual(part, *m.part(part.Number)) {
+			return errors.New("invalid part")
+		}
+		content = append(content, m.parts[part.Number]...)
+	}
+	m.memoryDriver[upload.Key] = content
+	return nil
+}
+
+func (m *multipartDriver) AbortUpload(upload *Upload) error {
+	return m.AbortUploadContext(context.Background(), upload)
+}
+
+func (m *multipartDriver) AbortUploadContext(ctx context.Context, upload *Upload) error {
+	m.parts = nil
+	return nil
+}
+
+func TestUploadResumable(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	tests := []struct {
+		name         string
+		content      []byte
+		partSize     int64
+		previous     map[int][]byte
+		wantUploaded []int
+	}{
+		{
+			name:         "new upload",
+			content:      content,
+			partSize:     8,
+			wantUploaded: []int{1, 2, 3},
+		},
+		{
+			name:         "resumed upload",
+			content:      content,
+			partSize:     8,
+			previous:     map[int][]byte{1: content[:8], 2: content[8:16]},
+			wantUploaded: []int{3},
+		},
+		{
+			name:         "incomplete part",
+			content:      content,
+			partSize:     8,
+			previous:     map[int][]byte{1: content[:8], 2: content[8:10]},
+			wantUploaded: []int{2, 3},
+		},
+		{
+			name:         "changed content",
+			content:      content,
+			partSize:     8,
+			previous:     map[int][]byte{1: []byte("76543210"), 2: content[8:16]},
+			wantUploaded: []int{1, 3},
+		},
+		{
+			name:         "single part",
+			content:      content,
+			partSize:     64,
+			wantUploaded: []int{1},
+		},
+		{
+			name:         "empty content",
+			content:      []byte{},
+			partSize:     8,
+			wantUploaded: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &multipartDriver{memoryDriver: memoryDriver{}}
+			upload, _ := d.InitiateUpload("test.txt", WriteOptions{})
+			for number, bts := range tt.previous {
+				d.parts[number] = bts
+			}
+
+			err := UploadResumable(context.Background(), d, upload, bytes.NewReader(tt.content), int64(len(tt.content)), tt.partSize)
+			if err != nil {
+				t.Errorf("UploadResumable() error = %v", err)
+				return
+			}
+			if !bytes.Equal(d.memoryDriver["test.txt"], tt.content) {
+				t.Errorf("UploadResumable() wrote %q, want %q", d.memoryDriver["test.txt"], tt.content)
+			}
+			if !reflect.DeepEqual(d.uploaded, tt.wantUploaded) {
+				t.Errorf("UploadResumable() uploaded parts %v, want %v", d.uploaded, tt.wantUploaded)
+			}
+		})
+	}
+}
+
+func TestUploadResumable_notSupported(t *testing.T) {
+	upload := &Upload{Key: "test.txt", UploadID: "upload"}
+	err := UploadResumable(context.Background(), memoryDriver{}, upload, strings.NewReader("test"), 4, 2)
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("UploadResumable() error = %v, want %v", err, ErrNotSupported)
+	}
+}
@@ -0,0 +1,76 @@
+package gostorage
+
+import "time"
+
+// ObjectInfo describes a file/object without its content.
+type ObjectInfo struct {
+	// Key is the key of the file/object.
+	Key string
+	// Size is the size of the content in bytes.
+	Size int64
+	// LastModified is the time the file/object was modified the last time.
+	LastModified time.Time
+	// ContentType is the MIME type of the content.
+	ContentType string
+	// CacheControl is the Cache-Control header the file/object has been written with.
+	CacheControl string
+	// ContentDisposition is the Content-Disposition header the file/object has been written with.
+	ContentDisposition string
+	// ContentEncoding is the Content-Encoding header the file/object has been written with.
+	ContentEncoding string
+	// ETag identifies the content of the file/object.
+	// It is the hex encoded MD5 checksum of the content, unless the object
+	// has been uploaded in multiple parts to S3. The value is never quoted.
+	ETag string
+	// Metadata contains the user defined metadata of the file/object.
+	// The keys are always lower case.
+	Metadata map[string]string
+	// VersionID identifies the version of the file/object if the driver keeps versions.
+	VersionID string
+}
+
+// NullVersionID is the version ID of a file/object that has been written
+// before versioning has been enabled.
+const NullVersionID = "null"
+
+// ObjectVersion describes a version of a file/object.
+type ObjectVersion struct {
+	// Key is the key of the file/object.
+	Key string
+	// VersionID identifies the version.
+	VersionID string
+	// Size is the size of the content of the version in bytes.
+	Size int64
+	// LastModified is the time the version has been created.
+	LastModified time.Time
+	// ETag identifies the content of the version. It is never quoted.
+	ETag string
+	// IsLatest reports whether the version is the latest version of the file/object.
+	IsLatest bool
+	// DeleteMarker reports whether the version marks the file/object as deleted.
+	// Delete markers do not have any content.
+	DeleteMarker bool
+}
+
+// WriteOptions defines the optional attributes a file/object is written with.
+// The zero value writes the content without any additional attributes.
+type WriteOptions struct {
+	// ContentType overrides the MIME type that is detected from the content.
+	ContentType string
+	// CacheControl defines the Cache-Control header of the file/object.
+	CacheControl string
+	// ContentDisposition defines the Content-Disposition header of the file/object.
+	ContentDisposition string
+	// ContentEncoding defines the Content-Encoding header of the file/object.
+	ContentEncoding string
+	// Metadata defines the user defined metadata of the file/object.
+	// The keys are case insensitive and are stored in lower case.
+	Metadata map[string]string
+	// IfNotExists only writes the file/object if it does not exist yet.
+	// ErrPreconditionFailed is returned if it already exists.
+	IfNotExists bool
+	// IfMatch only replaces the file/object if its current ETag equals the given ETag.
+	// ErrPreconditionFailed is returned if the ETag differs or the file/object does not exist.
+	// IfMatch can not be combined with IfNotExists.
+	IfMatch string
+}
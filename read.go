@@ -0,0 +1,171 @@
+package gostorage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+)
+
+// ReadStream returns a reader streaming the content of the file/object.
+// The caller must close the returned reader.
+// If the driver implements StreamReader, the content is streamed by the driver.
+// Otherwise the reader returned by Read is used.
+func ReadStream(ctx context.Context, d Driver, key string) (io.ReadCloser, error) {
+	if sr, ok := d.(StreamReader); ok {
+		return sr.ReadStreamContext(ctx, key)
+	}
+	r, err := AsDriverContext(d).ReadContext(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(r), nil
+}
+
+// ReadRange returns a reader streaming the byte range of the file/object
+// as documented by RangeReader. The caller must close the returned reader.
+// If the driver implements RangeReader, only the range is read by the driver.
+// Otherwise the whole content is read into memory and the range is cut from it.
+func ReadRange(ctx context.Context, d Driver, key string, offset, length int64) (io.ReadCloser, error) {
+	if rr, ok := d.(RangeReader); ok {
+		return rr.ReadRangeContext(ctx, key, offset, length)
+	}
+	if length == 0 || (offset < 0 && length > 0) {
+		return nil, fmt.Errorf("%w: offset %d, length %d", ErrInvalidRange, offset, length)
+	}
+	content, err := readAll(ctx, d, key)
+	if err != nil {
+		return nil, err
+	}
+	size := content.Size()
+	start, n := offset, length
+	switch {
+	case offset < 0:
+		if size == 0 {
+			return nil, fmt.Errorf("%w: suffix of %d bytes of empty content", ErrInvalidRange, -offset)
+		}
+		start = size + offset
+		if start < 0 {
+			start = 0
+		}
+		n = size - start
+	case offset >= size:
+		return nil, fmt.Errorf("%w: offset %d beyond size %d", ErrInvalidRange, offset, size)
+	case length < 0 || offset+length > size:
+		n = size - offset
+	}
+	return ioutil.NopCloser(io.NewSectionReader(content, start, n)), nil
+}
+
+// OpenReaderAt returns random access to the content of the file/object.
+// The caller must close the returned ReadAtCloser.
+// If the driver implements RangeReader, the content is accessed by the driver.
+// Otherwise the whole content is read into memory.
+func OpenReaderAt(ctx context.Context, d Driver, key string) (ReadAtCloser, error) {
+	if rr, ok := d.(RangeReader); ok {
+		return rr.OpenReaderAtContext(ctx, key)
+	}
+	content, err := readAll(ctx, d, key)
+	if err != nil {
+		return nil, err
+	}
+	return bytesReaderAt{content}, nil
+}
+
+// readAll reads the whole content of the file/object into memory.
+func readAll(ctx context.Context, d Driver, key string) (*bytes.Reader, error) {
+	rc, err := ReadStream(ctx, d, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	bts, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(bts), nil
+}
+
+// bytesReaderAt provides random access to content held in memory.
+type bytesReaderAt struct {
+	*bytes.Reader
+}
+
+func (b bytesReaderAt) Close() error {
+	return nil
+}
+
+// rangeReadSeeker streams the content of the file/object from the current offset.
+// The stream is opened by the first Read after the offset has been changed by Seek,
+// so that reading the content sequentially, from any offset, takes a single request.
+type rangeReadSeeker struct {
+	ctx    context.Context
+	driver Driver
+	key    string
+	size   int64
+	offset int64
+	stream io.ReadCloser
+}
+
+// newRangeReadSeeker returns the rangeReadSeeker of the file/object of the given size.
+func newRangeReadSeeker(ctx context.Context, d Driver, key string, size int64) *rangeReadSeeker {
+	return &rangeReadSeeker{
+		ctx:    ctx,
+		driver: d,
+		key:    key,
+		size:   size,
+	}
+}
+
+func (r *rangeReadSeeker) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.stream == nil {
+		var err error
+		if r.offset == 0 {
+			r.stream, err = ReadStream(r.ctx, r.driver, r.key)
+		} else {
+			r.stream, err = ReadRange(r.ctx, r.driver, r.key, r.offset, -1)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.stream.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *rangeReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, fmt.Errorf("%w: whence %d", fs.ErrInvalid, whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("%w: negative offset %d", fs.ErrInvalid, offset)
+	}
+	if offset != r.offset && r.stream != nil {
+		// the stream is opened again at the new offset by the next Read
+		r.stream.Close()
+		r.stream = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *rangeReadSeeker) Close() error {
+	if r.stream == nil {
+		return nil
+	}
+	err := r.stream.Close()
+	r.stream = nil
+	return err
+}
@@ -0,0 +1,126 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestReadStream(t *testing.T) {
+	d := memoryDriver{"test.txt": []byte("test")}
+	rc, err := ReadStream(context.Background(), d, "test.txt")
+	if err != nil {
+		t.Errorf("ReadStream() error = %v", err)
+		return
+	}
+	defer rc.Close()
+	got, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Errorf("ReadAll() error = %v", err)
+		return
+	}
+	if string(got) != "test" {
+		t.Errorf("ReadStream() = %q, want %q", got, "test")
+	}
+
+	_, err = ReadStream(context.Background(), d, "missing.txt")
+	if !errors.Is(err, ErrNotExist) {
+		t.Errorf("ReadStream() error = %v, want %v", err, ErrNotExist)
+	}
+}
+
+func TestReadRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  int64
+		length  int64
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:   "range",
+			offset: 2,
+			length: 3,
+			want:   []byte("234"),
+		},
+		{
+			name:   "range beyond end",
+			offset: 8,
+			length: 5,
+			want:   []byte("89"),
+		},
+		{
+			name:   "until end",
+			offset: 6,
+			length: -1,
+			want:   []byte("6789"),
+		},
+		{
+			name:   "suffix",
+			offset: -3,
+			length: -1,
+			want:   []byte("789"),
+		},
+		{
+			name:   "suffix longer than content",
+			offset: -20,
+			length: -1,
+			want:   []byte("0123456789"),
+		},
+		{
+			name:    "offset beyond end",
+			offset:  10,
+			length:  1,
+			wantErr: ErrInvalidRange,
+		},
+		{
+			name:    "zero length",
+			offset:  0,
+			length:  0,
+			wantErr: ErrInvalidRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := memoryDriver{"test.txt": []byte("0123456789")}
+			rc, err := ReadRange(context.Background(), d, "test.txt", tt.offset, tt.length)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadRange() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+			got, err := ioutil.ReadAll(rc)
+			if err != nil {
+				t.Errorf("ReadAll() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadRange() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenReaderAt(t *testing.T) {
+	d := memoryDriver{"test.txt": []byte("0123456789")}
+	ra, err := OpenReaderAt(context.Background(), d, "test.txt")
+	if err != nil {
+		t.Errorf("OpenReaderAt() error = %v", err)
+		return
+	}
+	defer ra.Close()
+	if ra.Size() != 10 {
+		t.Errorf("Size() = %v, want %v", ra.Size(), 10)
+	}
+	p := make([]byte, 5)
+	n, err := ra.ReadAt(p, 8)
+	if !errors.Is(err, io.EOF) || string(p[:n]) != "89" {
+		t.Errorf("ReadAt() = %q, %v, want %q, %v", p[:n], err, "89", io.EOF)
+	}
+}
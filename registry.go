@@ -0,0 +1,61 @@
+package gostorage
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Factory creates a driver from the URL it has been opened with.
+type Factory func(u *url.URL) (Driver, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a driver available by the URL scheme for Open.
+// It is intended to be called from the init function of the package implementing the driver.
+// If Register is called twice with the same scheme or if the factory is nil, it panics.
+func Register(scheme string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("gostorage: Register factory is nil")
+	}
+	if _, dup := registry[scheme]; dup {
+		panic("gostorage: Register called twice for scheme " + scheme)
+	}
+	registry[scheme] = factory
+}
+
+// Schemes returns the sorted list of the registered URL schemes.
+func Schemes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	schemes := make([]string, 0, len(registry))
+	for scheme := range registry {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Open creates the driver registered for the scheme of the URL, e.g.
+// "s3://bucket/prefix?region=eu-central-1" or "file:///var/data".
+// The drivers of this module are registered by importing the drivers package.
+// ErrNotSupported is returned if no driver is registered for the scheme.
+func Open(rawURL string) (Driver, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	registryMu.RLock()
+	factory, ok := registry[u.Scheme]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown scheme %q (forgotten import?)", ErrNotSupported, u.Scheme)
+	}
+	return factory(u)
+}
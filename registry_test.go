@@ -0,0 +1,115 @@
+package gostorage
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// registerTestFactory is the factory registered for the scheme "register-test".
+func registerTestFactory(u *url.URL) (Driver, error) {
+	return memoryDriver{}, nil
+}
+
+// the schemes are registered once, as they can not be registered again
+// if the tests are run multiple times
+func init() {
+	Register("memory-test", func(u *url.URL) (Driver, error) {
+		if u.Host == "" {
+			return nil, errors.New("missing host")
+		}
+		return memoryDriver{u.Host: []byte(u.Path)}, nil
+	})
+	Register("register-test", registerTestFactory)
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		want    Driver
+		wantErr bool
+	}{
+		{
+			name:    "registered scheme",
+			rawURL:  "memory-test://test.txt/content",
+			want:    memoryDriver{"test.txt": []byte("/content")},
+			wantErr: false,
+		},
+		{
+			name:    "factory error",
+			rawURL:  "memory-test:///content",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "unknown scheme",
+			rawURL:  "unknown://bucket",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "invalid url",
+			rawURL:  "memory-test://%zz",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Open(tt.rawURL)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Open() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Open() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, err := Open("unknown://bucket")
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Open() error = %v, want %v", err, ErrNotSupported)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	found := false
+	for _, scheme := range Schemes() {
+		if scheme == "register-test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Schemes() = %v, want to contain %q", Schemes(), "register-test")
+	}
+
+	tests := []struct {
+		name    string
+		scheme  string
+		factory Factory
+	}{
+		{
+			name:    "duplicate scheme",
+			scheme:  "register-test",
+			factory: registerTestFactory,
+		},
+		{
+			name:    "nil factory",
+			scheme:  "register-nil-test",
+			factory: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register() did not panic")
+				}
+			}()
+			Register(tt.scheme, tt.factory)
+		})
+	}
+}
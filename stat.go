@@ -0,0 +1,43 @@
+package gostorage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+
+	"github.com/leonsteinhaeuser/go-storage-abstraction/utils"
+)
+
+// Stat returns the metadata of the file/object.
+// If the driver implements Stater, the metadata is returned by the driver.
+// Otherwise the content is read to determine the size, the MIME type and the ETag,
+// while LastModified and all attributes stay empty.
+func Stat(ctx context.Context, d Driver, key string) (*ObjectInfo, error) {
+	if s, ok := d.(Stater); ok {
+		return s.StatContext(ctx, key)
+	}
+	content, err := readAll(ctx, d, key)
+	if err != nil {
+		return nil, err
+	}
+	mType, err := utils.MimeType(content)
+	if err != nil {
+		return nil, err
+	}
+	_, err = content.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	hash := md5.New()
+	_, err = io.Copy(hash, content)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{
+		Key:         key,
+		Size:        content.Size(),
+		ContentType: mType,
+		ETag:        hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
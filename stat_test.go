@@ -0,0 +1,46 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStat(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    *ObjectInfo
+		wantErr error
+	}{
+		{
+			name: "found",
+			key:  "test.txt",
+			want: &ObjectInfo{
+				Key:         "test.txt",
+				Size:        4,
+				ContentType: "text/plain; charset=utf-8",
+				ETag:        "098f6bcd4621d373cade4e832627b4f6",
+			},
+		},
+		{
+			name:    "not found",
+			key:     "missing.txt",
+			wantErr: ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := memoryDriver{"test.txt": []byte("test")}
+			got, err := Stat(context.Background(), d, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Stat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"context"
+	"io"
+)
+
+// ContextReader returns a reader that stops reading from the input reader
+// once the context is cancelled or its deadline is exceeded.
+func ContextReader(ctx context.Context, input io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, input: input}
+}
+
+type contextReader struct {
+	ctx   context.Context
+	input io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.input.Read(p)
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestContextReader(t *testing.T) {
+	type args struct {
+		ctx   func() context.Context
+		input io.Reader
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "active context",
+			args: args{
+				ctx:   context.Background,
+				input: bytes.NewBufferString("hello, world"),
+			},
+			want:    []byte("hello, world"),
+			wantErr: false,
+		},
+		{
+			name: "cancelled context",
+			args: args{
+				ctx: func() context.Context {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					return ctx
+				},
+				input: bytes.NewBufferString("hello, world"),
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ioutil.ReadAll(ContextReader(tt.args.ctx(), tt.args.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ContextReader() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("ContextReader() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
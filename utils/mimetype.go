@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 
@@ -8,6 +9,7 @@ import (
 )
 
 // MimeType returns the MIME type of the input reader.
+// The bytes used for the detection are consumed from the input.
 func MimeType(input io.Reader) (string, error) {
 	mType, err := mimetype.DetectReader(input)
 	if err != nil {
@@ -15,3 +17,15 @@ func MimeType(input io.Reader) (string, error) {
 	}
 	return mType.String(), nil
 }
+
+// PeekMimeType returns the MIME type of the input reader together with a reader
+// that returns the whole content of the input, including the bytes that have been
+// read for the detection. The input must not be used afterwards.
+func PeekMimeType(input io.Reader) (string, io.Reader, error) {
+	peeked := &bytes.Buffer{}
+	mType, err := mimetype.DetectReader(io.TeeReader(input, peeked))
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to detect MimeType: %w", err)
+	}
+	return mType.String(), io.MultiReader(peeked, input), nil
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"io"
+	"io/ioutil"
 	"testing"
 	"testing/fstest"
 )
@@ -59,3 +60,58 @@ func TestMimeType(t *testing.T) {
 		})
 	}
 }
+
+func TestPeekMimeType(t *testing.T) {
+	large := bytes.Repeat([]byte("hello world\n"), 1024)
+	tests := []struct {
+		name    string
+		input   []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "text",
+			input:   []byte("hello, world"),
+			want:    "text/plain; charset=utf-8",
+			wantErr: false,
+		},
+		{
+			name:    "larger than the detection limit",
+			input:   large,
+			want:    "text/plain; charset=utf-8",
+			wantErr: false,
+		},
+		{
+			name:    "png",
+			input:   []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+			want:    "image/png",
+			wantErr: false,
+		},
+		{
+			name:    "empty",
+			input:   []byte{},
+			want:    "text/plain",
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, content, err := PeekMimeType(bytes.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PeekMimeType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("PeekMimeType() = %v, want %v", got, tt.want)
+			}
+			bts, err := ioutil.ReadAll(content)
+			if err != nil {
+				t.Errorf("PeekMimeType() reader error = %v", err)
+				return
+			}
+			if !bytes.Equal(bts, tt.input) {
+				t.Errorf("PeekMimeType() reader returned %d bytes, want the %d bytes of the input", len(bts), len(tt.input))
+			}
+		})
+	}
+}
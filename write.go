@@ -0,0 +1,28 @@
+package gostorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// WriteWithOptions writes the value to the file/object with the attributes and conditions of opts.
+// If the driver implements OptionsWriter, the options are applied by the driver.
+// Otherwise the value is written by Write if opts does not define anything.
+//
+// ErrNotSupported is returned if opts defines conditions and the driver does not report
+// CapabilityConditionalWrite, or if opts defines attributes the driver can not store.
+func WriteWithOptions(ctx context.Context, d Driver, key string, value io.Reader, opts WriteOptions) error {
+	conditional := opts.IfNotExists || opts.IfMatch != ""
+	if ow, ok := d.(OptionsWriter); ok && (!conditional || HasCapability(d, CapabilityConditionalWrite)) {
+		return ow.WriteWithOptionsContext(ctx, key, value, opts)
+	}
+	if conditional {
+		return fmt.Errorf("%w: conditional writes", ErrNotSupported)
+	}
+	if opts.ContentType != "" || opts.CacheControl != "" || opts.ContentDisposition != "" ||
+		opts.ContentEncoding != "" || len(opts.Metadata) > 0 {
+		return fmt.Errorf("%w: write options", ErrNotSupported)
+	}
+	return AsDriverContext(d).WriteContext(ctx, key, value)
+}
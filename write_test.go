@@ -0,0 +1,62 @@
+package gostorage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteWithOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       Driver
+		opts    WriteOptions
+		want    memoryDriver
+		wantErr error
+	}{
+		{
+			name: "no options",
+			d:    memoryDriver{},
+			opts: WriteOptions{},
+			want: memoryDriver{"test.txt": []byte("test")},
+		},
+		{
+			name:    "attributes",
+			d:       memoryDriver{},
+			opts:    WriteOptions{ContentType: "text/plain"},
+			want:    memoryDriver{},
+			wantErr: ErrNotSupported,
+		},
+		{
+			name:    "conditions",
+			d:       memoryDriver{},
+			opts:    WriteOptions{IfNotExists: true},
+			want:    memoryDriver{},
+			wantErr: ErrNotSupported,
+		},
+		{
+			name:    "conditions of a driver reporting conditional writes without OptionsWriter",
+			d:       reportingDriver{memoryDriver: memoryDriver{}, capabilities: CapabilityConditionalWrite},
+			opts:    WriteOptions{IfNotExists: true},
+			want:    memoryDriver{},
+			wantErr: ErrNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WriteWithOptions(context.Background(), tt.d, "test.txt", strings.NewReader("test"), tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WriteWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := tt.d
+			if r, ok := got.(reportingDriver); ok {
+				got = r.memoryDriver
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WriteWithOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}